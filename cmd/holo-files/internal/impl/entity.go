@@ -23,17 +23,18 @@ package impl
 import (
 	"errors"
 	"fmt"
-	"os"
 	"path/filepath"
 	"sort"
 
 	"github.com/holocm/holo/cmd/holo-files/internal/common"
+	"github.com/holocm/holo/internal/pluginapi"
 )
 
 // Entity represents a configuration file that can be provisioned by holo-files.
 type Entity struct {
 	relPath   string // the entity path relative to the common.TargetDirectory()
 	resources Resources
+	owner     string // the user whose home directory contains the target, or ""
 }
 
 // NewEntity creates a Entity instance for which a path is known.
@@ -85,6 +86,20 @@ func (entity *Entity) PrintReport() {
 			fmt.Printf("SOURCE: %s\n", resource.Path())
 			fmt.Printf("%s: %s\n", resource.ApplicationStrategy(), resource.Path())
 		}
+		//several resources may have the same tag, but the tag needs to be
+		//reported only once
+		seenTags := make(map[string]bool)
+		for _, resource := range entity.Resources() {
+			tag := resource.Tag()
+			if !seenTags[tag] {
+				seenTags[tag] = true
+				fmt.Printf("TAG: %s\n", tag)
+			}
+		}
+		if entity.owner != "" {
+			fmt.Printf("REQUIRES: user:%s\n", entity.owner)
+		}
+		fmt.Printf("WATCH: %s\n", entity.PathIn(common.TargetDirectory()))
 	}
 }
 
@@ -96,20 +111,42 @@ var ErrNeedForceToOverwrite = errors.New("NeedForceToOverwrite")
 // chain.
 var ErrNeedForceToRestore = errors.New("NeedForceToRestore")
 
-// Apply applies the entity.
-func (entity *Entity) Apply(withForce bool) (skipReport, needForceToOverwrite, needForceToRestore bool) {
+// Apply applies the entity. With dryRun, no changes are made. Instead, the
+// changes that would be made are described on stdout. Errors are reported on
+// stderr, and signaled by the failed return value.
+//
+// Unless dryRun is set, the prior state of the entity is recorded in the
+// journal directory given by Holo if the entity is changed.
+func (entity *Entity) Apply(withForce, dryRun bool) (skipReport, needForceToOverwrite, needForceToRestore, failed bool) {
+	if !dryRun {
+		err := entity.saveToJournal()
+		if err != nil {
+			pluginapi.Errorf("cannot record prior state in journal: %s", err.Error())
+			return false, false, false, true
+		}
+		defer func() {
+			if skipReport || needForceToOverwrite || needForceToRestore {
+				err := entity.discardJournal()
+				if err != nil {
+					pluginapi.Errorf(err.Error())
+				}
+			}
+		}()
+	}
+
 	if len(entity.resources) == 0 {
-		errs := entity.applyOrphan()
+		errs := entity.applyOrphan(dryRun)
 		skipReport = false
 		needForceToOverwrite = false
 		needForceToRestore = false
+		failed = len(errs) > 0
 
 		for _, err := range errs {
-			fmt.Fprintf(os.Stderr, "!! %s\n", err.Error())
+			pluginapi.Errorf(err.Error())
 		}
 	} else {
 		var err error
-		skipReport, err = entity.applyNonOrphan(withForce)
+		skipReport, err = entity.applyNonOrphan(withForce, dryRun)
 
 		//special cases for errors that signal command messages
 		needForceToOverwrite = err == ErrNeedForceToOverwrite
@@ -119,7 +156,8 @@ func (entity *Entity) Apply(withForce bool) (skipReport, needForceToOverwrite, n
 		}
 
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "!! %s\n", err.Error())
+			pluginapi.Errorf(err.Error())
+			failed = true
 		}
 	}
 	return
@@ -35,57 +35,28 @@ import (
 // This includes taking a copy of the base if necessary, applying all
 // resources, and saving the result in the target path with the correct
 // file metadata.
-func (entity *Entity) applyNonOrphan(withForce bool) (skipReport bool, err error) {
-	//step 1: check if a system update installed a new version of the stock
-	//configuration
-	//
-	// This has to come first because it might shuffle some files
-	// around, and if we do anything else first, we might end up
-	// stat()ing the wrong file.
-	newBasePath, newBase, err := entity.GetNewBase()
+//
+// With dryRun, no changes are made. Instead, the changes that would be made are
+// described on stdout.
+func (entity *Entity) applyNonOrphan(withForce, dryRun bool) (skipReport bool, err error) {
+	v, err := entity.loadVersions(dryRun)
 	if err != nil {
 		return false, err
 	}
-
-	// step 2: Load our 3 versions into memory.
-	current, err := entity.GetCurrent()
-	if err != nil && !os.IsNotExist(err) {
-		if pe, ok := err.(*os.PathError); ok {
-			err = errors.New("skipping target: " + pe.Err.Error())
-		}
-		return false, err
-	}
-
-	base, err := entity.GetBase()
-	if err != nil && !os.IsNotExist(err) {
-		if pe, ok := err.(*os.PathError); ok {
-			err = errors.New("skipping target: " + pe.Err.Error())
-		}
-		return false, err
-	}
-
-	provisioned, err := entity.GetProvisioned()
-	if err != nil && !os.IsNotExist(err) {
-		if pe, ok := err.(*os.PathError); ok {
-			err = errors.New("skipping target: " + pe.Err.Error())
-		}
-		return false, err
-	}
+	newBasePath, newBase, current, base, provisioned := v.newBasePath, v.newBase, v.current, v.base, v.provisioned
 
 	////////////////////////////////////////////////////////////////////////
 
 	//step 1: if we don't have a base yet, the file at current *is*
 	//the base which we have to copy now
 	if !base.Manageable && current.Manageable {
-		baseDir := filepath.Dir(base.Path)
-		err := os.MkdirAll(baseDir, 0755)
-		if err != nil {
-			return false, fmt.Errorf("Cannot create directory %s: %s", baseDir, err.Error())
-		}
-
-		err = current.Write(base.Path)
-		if err != nil {
-			return false, fmt.Errorf("Cannot copy %s to %s: %s", current.Path, base.Path, err.Error())
+		if dryRun {
+			fmt.Printf("copy %s to %s\n", current.Path, base.Path)
+		} else {
+			err := entity.storeBase(current, base.Path)
+			if err != nil {
+				return false, err
+			}
 		}
 		tmp := current
 		tmp.Path = base.Path
@@ -108,12 +79,16 @@ func (entity *Entity) applyNonOrphan(withForce bool) (skipReport bool, err error
 	if newBase.Manageable {
 		//an updated stock configuration is available at newBase.Path
 		//(but show it to the user as newBasePath)
-		fmt.Printf(">> found updated target base: %s -> %s\n", newBasePath, base.Path)
-		err := newBase.Write(base.Path)
-		if err != nil {
-			return false, fmt.Errorf("Cannot copy %s to %s: %v", newBase.Path, base.Path, err)
+		if dryRun {
+			fmt.Printf("move updated target base %s to %s\n", newBasePath, base.Path)
+		} else {
+			fmt.Printf(">> found updated target base: %s -> %s\n", newBasePath, base.Path)
+			err := newBase.Write(base.Path)
+			if err != nil {
+				return false, fmt.Errorf("Cannot copy %s to %s: %v", newBase.Path, base.Path, err)
+			}
+			_ = os.Remove(newBase.Path) //this can fail silently
 		}
-		_ = os.Remove(newBase.Path) //this can fail silently
 		newBase.Path = base.Path
 		base = newBase
 	}
@@ -145,6 +120,16 @@ func (entity *Entity) applyNonOrphan(withForce bool) (skipReport bool, err error
 
 	//save a copy of the provisioned config file to check for manual
 	//modifications in the next Apply() run
+	if dryRun {
+		if !desired.EqualTo(provisioned) {
+			fmt.Printf("write %s\n", provisioned.Path)
+		}
+		if desired.EqualTo(current) {
+			return true, nil
+		}
+		fmt.Printf("write %s\n", current.Path)
+		return false, nil
+	}
 	if !desired.EqualTo(provisioned) {
 		provisionedDir := filepath.Dir(provisioned.Path)
 		err = os.MkdirAll(provisionedDir, 0755)
@@ -172,6 +157,83 @@ func (entity *Entity) applyNonOrphan(withForce bool) (skipReport bool, err error
 	return true, nil
 }
 
+// entityVersions contains the versions of an entity that the application
+// algorithm works with. Versions that do not exist have Manageable = false.
+type entityVersions struct {
+	newBasePath string //where the user finds the newBase (e.g. "/etc/foo.conf.pacnew")
+	newBase     common.FileBuffer
+	current     common.FileBuffer
+	base        common.FileBuffer
+	provisioned common.FileBuffer
+}
+
+// loadVersions loads all versions of this entity into memory. With dryRun,
+// no files are moved around.
+func (entity *Entity) loadVersions(dryRun bool) (v entityVersions, err error) {
+	//step 1: check if a system update installed a new version of the stock
+	//configuration
+	//
+	// This has to come first because it might shuffle some files
+	// around, and if we do anything else first, we might end up
+	// stat()ing the wrong file.
+	if dryRun {
+		var currentPath string
+		v.newBasePath, v.newBase, currentPath, err = entity.PeekNewBase()
+		if err != nil {
+			return v, err
+		}
+		// step 2: Load our 3 versions into memory. (When planning, the current
+		// version might not be at the target path yet, see above.)
+		v.current, err = common.NewFileBuffer(currentPath)
+		v.current.Path = entity.PathIn(common.TargetDirectory())
+	} else {
+		v.newBasePath, v.newBase, err = entity.GetNewBase()
+		if err != nil {
+			return v, err
+		}
+		// step 2: Load our 3 versions into memory.
+		v.current, err = entity.GetCurrent()
+	}
+	if err != nil && !os.IsNotExist(err) {
+		if pe, ok := err.(*os.PathError); ok {
+			err = errors.New("skipping target: " + pe.Err.Error())
+		}
+		return v, err
+	}
+
+	v.base, err = entity.GetBase()
+	if err != nil && !os.IsNotExist(err) {
+		if pe, ok := err.(*os.PathError); ok {
+			err = errors.New("skipping target: " + pe.Err.Error())
+		}
+		return v, err
+	}
+
+	v.provisioned, err = entity.GetProvisioned()
+	if err != nil && !os.IsNotExist(err) {
+		if pe, ok := err.(*os.PathError); ok {
+			err = errors.New("skipping target: " + pe.Err.Error())
+		}
+		return v, err
+	}
+	return v, nil
+}
+
+// storeBase records the given buffer as the base version of this entity.
+func (entity *Entity) storeBase(buf common.FileBuffer, basePath string) error {
+	baseDir := filepath.Dir(basePath)
+	err := os.MkdirAll(baseDir, 0755)
+	if err != nil {
+		return fmt.Errorf("Cannot create directory %s: %s", baseDir, err.Error())
+	}
+
+	err = buf.Write(basePath)
+	if err != nil {
+		return fmt.Errorf("Cannot copy %s to %s: %s", buf.Path, basePath, err.Error())
+	}
+	return nil
+}
+
 // GetBase return the package manager-supplied base version of the
 // entity, as recorded the last time it was provisioned.
 func (entity *Entity) GetBase() (common.FileBuffer, error) {
@@ -203,6 +265,17 @@ func (entity *Entity) GetNewBase() (path string, buf common.FileBuffer, err erro
 	return
 }
 
+// PeekNewBase is the read-only counterpart of GetNewBase. It does not move any
+// files around, and additionally returns the path where the current version of
+// the entity can be found.
+func (entity *Entity) PeekNewBase() (path string, buf common.FileBuffer, currentPath string, err error) {
+	realPath, currentPath, path := platform.Implementation().PeekUpdatedTargetBase(entity.PathIn(common.TargetDirectory()))
+	if realPath != "" {
+		buf, err = common.NewFileBuffer(realPath)
+	}
+	return path, buf, currentPath, err
+}
+
 // GetDesired applies all the resources for this Entity onto the base.
 func (entity *Entity) GetDesired(base common.FileBuffer) (common.FileBuffer, error) {
 	resources := entity.Resources()
@@ -40,14 +40,22 @@ func (entity *Entity) scanOrphan() (targetPath, strategy, assessment string) {
 	return targetPath, "delete", "target was deleted"
 }
 
-// applyOrphan cleans up an orphaned entity.
-func (entity *Entity) applyOrphan() []error {
+// applyOrphan cleans up an orphaned entity. With dryRun, no changes are made.
+// Instead, the changes that would be made are described on stdout.
+func (entity *Entity) applyOrphan(dryRun bool) []error {
 	var errs []error
 	appendError := func(err error) {
 		if err != nil {
 			errs = append(errs, err)
 		}
 	}
+	remove := func(path string) {
+		if dryRun {
+			fmt.Printf("delete %s\n", path)
+		} else {
+			appendError(os.Remove(path))
+		}
+	}
 
 	current, err := entity.GetCurrent()
 	if !os.IsNotExist(err) {
@@ -70,27 +78,45 @@ func (entity *Entity) applyOrphan() []error {
 				continue
 			}
 			if otherFile.EqualTo(provisioned) {
-				fmt.Printf(">> also deleting %s\n", otherFile.Path)
-				appendError(os.Remove(otherFile.Path))
+				if !dryRun {
+					fmt.Printf(">> also deleting %s\n", otherFile.Path)
+				}
+				remove(otherFile.Path)
 			}
 		}
 
-		appendError(os.Remove(provisioned.Path))
-		appendError(os.Remove(basePath))
+		remove(provisioned.Path)
+		remove(basePath)
 	} else { // restore
 		//target is still there - restore the target base, *but* before that,
 		//check if there is an updated target base
-		updatedTBPath, reportedTBPath, err := platform.Implementation().FindUpdatedTargetBase(current.Path)
-		appendError(err)
+		var updatedTBPath, currentPath, reportedTBPath string
+		if dryRun {
+			updatedTBPath, currentPath, reportedTBPath = platform.Implementation().PeekUpdatedTargetBase(current.Path)
+		} else {
+			updatedTBPath, reportedTBPath, err = platform.Implementation().FindUpdatedTargetBase(current.Path)
+			appendError(err)
+		}
 		if updatedTBPath != "" {
-			fmt.Printf(">> found updated target base: %s -> %s", reportedTBPath, current.Path)
+			if !dryRun {
+				fmt.Printf(">> found updated target base: %s -> %s", reportedTBPath, current.Path)
+			}
 			//use this target base instead of the one in the BaseDirectory
-			appendError(os.Remove(basePath))
+			remove(basePath)
 			basePath = updatedTBPath
 		}
 
-		appendError(os.Remove(provisioned.Path))
-		appendError(fs.MoveFile(basePath, current.Path))
+		remove(provisioned.Path)
+		switch {
+		case dryRun && basePath == current.Path:
+			//the updated target base is already in place, but the current
+			//target (e.g. a .dpkg-old file) will be overwritten by it
+			remove(currentPath)
+		case dryRun:
+			fmt.Printf("move %s to %s\n", basePath, current.Path)
+		default:
+			appendError(fs.MoveFile(basePath, current.Path))
+		}
 	}
 
 	//TODO: cleanup empty directories below BaseDirectory() and ProvisionedDirectory()
@@ -0,0 +1,73 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import "errors"
+
+// Check determines whether the entity is in its desired state without
+// changing anything. The returned status is one of the values defined for
+// the "check" operation in holo-plugin-interface(7): "in sync", "drifted",
+// "pending" or "orphaned".
+func (entity *Entity) Check() (status string, err error) {
+	if len(entity.resources) == 0 {
+		return "orphaned", nil
+	}
+
+	v, err := entity.loadVersions(true)
+	if err != nil {
+		return "", err
+	}
+	current, base, provisioned := v.current, v.base, v.provisioned
+
+	//this follows the same steps as applyNonOrphan()
+	if !base.Manageable && current.Manageable {
+		base = current
+	}
+	if !base.Manageable {
+		return "", errors.New("not a manageable file")
+	}
+	if !current.Manageable {
+		//target has been deleted by the user
+		return "drifted", nil
+	}
+	if v.newBase.Manageable {
+		base = v.newBase
+	}
+
+	desired, err := entity.GetDesired(base)
+	if err != nil {
+		return "", err
+	}
+	if current.EqualTo(desired) {
+		return "in sync", nil
+	}
+
+	//if the target is not in the desired state, it should at least be in the
+	//expected state (see applyNonOrphan())
+	expected := provisioned
+	if !provisioned.Manageable {
+		expected = base
+	}
+	if current.EqualTo(expected) {
+		return "pending", nil
+	}
+	return "drifted", nil
+}
@@ -0,0 +1,122 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+package impl
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/holocm/holo/cmd/holo-files/internal/common"
+)
+
+// DiffVersion returns the path of a file that contains the given version of
+// this entity ("base", "provisioned", "desired" or "current"), for the "diff"
+// operation. Versions that do not exist are reported as "/dev/null". Versions
+// that do not exist on disk are computed and written into $HOLO_CACHE_DIR.
+func (entity *Entity) DiffVersion(version string) (string, error) {
+	switch version {
+	case "provisioned":
+		return entity.PathIn(common.ProvisionedDirectory()), nil
+	case "current":
+		return entity.PathIn(common.TargetDirectory()), nil
+	case "base", "desired":
+		//see below
+	default:
+		return "", fmt.Errorf("unknown version %q", version)
+	}
+
+	v, err := entity.loadVersions(true)
+	if err != nil {
+		return "", err
+	}
+	isOrphan := len(entity.resources) == 0
+	base := entity.selectBase(v)
+
+	buf := base
+	if version == "desired" {
+		switch {
+		case isOrphan && !v.current.Manageable:
+			//orphaned entity will be deleted
+			buf = common.FileBuffer{}
+		case isOrphan:
+			//orphaned entity will be restored to its base
+		case !base.Manageable:
+			return "", errors.New("not a manageable file")
+		default:
+			buf, err = entity.GetDesired(base)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if !buf.Manageable {
+		return "/dev/null", nil
+	}
+	return entity.writeDiffVersion(version, buf)
+}
+
+// selectBase returns the version of this entity that the application
+// algorithm starts from. This follows the same steps as applyNonOrphan() and
+// applyOrphan().
+func (entity *Entity) selectBase(v entityVersions) common.FileBuffer {
+	base := v.base
+	if v.newBase.Manageable {
+		base = v.newBase
+	}
+	if !base.Manageable && len(entity.resources) > 0 {
+		base = v.current
+	}
+	return base
+}
+
+// writeDiffVersion writes a computed version of this entity into
+// $HOLO_CACHE_DIR, and returns the path to it.
+func (entity *Entity) writeDiffVersion(version string, buf common.FileBuffer) (string, error) {
+	return entity.writeBuffer(filepath.Join("diff", version), buf)
+}
+
+// writeBuffer writes the given buffer into the given subdirectory of
+// $HOLO_CACHE_DIR, and returns the path to it.
+func (entity *Entity) writeBuffer(subdir string, buf common.FileBuffer) (string, error) {
+	path := filepath.Join(os.Getenv("HOLO_CACHE_DIR"), subdir, entity.relPath)
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return "", err
+	}
+	err = os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	//unlike FileBuffer.Write(), do not chown the file (the "diff" operation
+	//can be run by non-root users)
+	if buf.Mode&os.ModeSymlink != 0 {
+		err = os.Symlink(buf.Contents, path)
+	} else {
+		err = os.WriteFile(path, []byte(buf.Contents), buf.Mode.Perm())
+	}
+	if err != nil {
+		return "", err
+	}
+	return path, nil
+}
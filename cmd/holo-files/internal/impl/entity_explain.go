@@ -0,0 +1,88 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/holocm/holo/cmd/holo-files/internal/common"
+)
+
+// ExplainStep describes one step of the computation of the desired version of
+// an entity, for the "explain" operation.
+type ExplainStep struct {
+	//"base" for the version that the computation starts from, otherwise the
+	//ApplicationStrategy() of the resource
+	Strategy string
+	//path to the base or to the resource
+	Source string
+	//path to the buffer after this step, or "" if the step was skipped
+	Result string
+}
+
+// Explain computes the desired version of this entity in the same way as
+// GetDesired(), but writes the buffer after each step into $HOLO_CACHE_DIR.
+// Steps that GetDesired() skips because a later resource discards their
+// result are reported with an empty Result.
+func (entity *Entity) Explain() ([]ExplainStep, error) {
+	v, err := entity.loadVersions(true)
+	if err != nil {
+		return nil, err
+	}
+	base := entity.selectBase(v)
+	if !base.Manageable {
+		return nil, errors.New("not a manageable file")
+	}
+
+	resources := entity.Resources()
+	firstStep := 0
+	for idx, resource := range resources {
+		if resource.DiscardsPreviousBuffer() {
+			firstStep = idx
+		}
+	}
+
+	basePath, err := entity.writeBuffer(filepath.Join("explain", "0"), base)
+	if err != nil {
+		return nil, err
+	}
+	steps := []ExplainStep{{Strategy: "base", Source: base.Path, Result: basePath}}
+
+	buffer := base
+	buffer.Path = entity.PathIn(common.TargetDirectory())
+	for idx, resource := range resources {
+		step := ExplainStep{Strategy: resource.ApplicationStrategy(), Source: resource.Path()}
+		if idx >= firstStep {
+			buffer, err = resource.ApplyTo(buffer)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", resource.Path(), err.Error())
+			}
+			step.Result, err = entity.writeBuffer(filepath.Join("explain", fmt.Sprint(idx+1)), buffer)
+			if err != nil {
+				return nil, err
+			}
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
@@ -0,0 +1,75 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+package impl
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// readHomeDirectories reads /etc/passwd below the given target directory and
+// returns the home directories of all users (relative to the target
+// directory) with the respective user names. Users whose home directory is
+// the root directory (e.g. "nobody" on some distributions) are ignored.
+func readHomeDirectories(targetDir string) map[string]string {
+	result := make(map[string]string)
+	file, err := os.Open(filepath.Join(targetDir, "etc/passwd"))
+	if err != nil {
+		//without a user database, no user can be required
+		return result
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		//format: name:password:UID:GID:GECOS:home:shell
+		fields := strings.Split(scanner.Text(), ":")
+		if len(fields) < 6 || fields[0] == "" || !filepath.IsAbs(fields[5]) {
+			continue
+		}
+		home := strings.TrimPrefix(filepath.Clean(fields[5]), "/")
+		if home != "" {
+			result[home] = fields[0]
+		}
+	}
+	return result
+}
+
+// ownerOfPath returns the name of the user whose home directory contains the
+// given path (relative to the target directory), or "" if there is none. If
+// home directories are nested, the innermost one wins. Paths below
+// /home/$NAME belong to the user $NAME unless /etc/passwd says otherwise,
+// since that user might not have been created yet.
+func ownerOfPath(relPath string, homeDirectories map[string]string) string {
+	for dir := filepath.Dir(relPath); dir != "." && dir != "/"; dir = filepath.Dir(dir) {
+		if name, exists := homeDirectories[dir]; exists {
+			return name
+		}
+	}
+
+	//fall back to the conventional location of home directories
+	fields := strings.SplitN(relPath, "/", 3)
+	if len(fields) == 3 && fields[0] == "home" && fields[1] != "" {
+		return fields[1]
+	}
+	return ""
+}
@@ -0,0 +1,68 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+package impl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOwnerOfPath(t *testing.T) {
+	dir := t.TempDir()
+	err := os.MkdirAll(filepath.Join(dir, "etc"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	passwd := "root:x:0:0::/root:/bin/sh\nnobody:x:65534:65534:Nobody:/:/usr/bin/nologin\n" +
+		"alice:x:1000:1000::/home/alice/:/bin/sh\ngit:x:1001:1001::/home/alice/git:/usr/bin/git-shell\n"
+	err = os.WriteFile(filepath.Join(dir, "etc/passwd"), []byte(passwd), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	homeDirectories := readHomeDirectories(dir)
+
+	expected := map[string]string{
+		"etc/motd":                  "", //"nobody" has "/" as home and is ignored
+		"home/alice/.profile":       "alice",
+		"home/alice/git/.gitconfig": "git", //the innermost home directory wins
+		"home/alice":                "",    //the home directory itself is not inside of it
+		"home/bob/.profile":         "bob", //users that do not exist yet are found by convention
+		"home/bob":                  "",
+		"home/.profile":             "",
+		"srv/bob/.profile":          "",
+		"root/.ssh/config":          "root",
+	}
+	for relPath, owner := range expected {
+		if actual := ownerOfPath(relPath, homeDirectories); actual != owner {
+			t.Errorf("expected owner of %s to be %q, got %q", relPath, owner, actual)
+		}
+	}
+
+	//without a user database, no home directories are known...
+	homeDirectories = readHomeDirectories(t.TempDir())
+	if len(homeDirectories) != 0 {
+		t.Errorf("expected no home directories, got %#v", homeDirectories)
+	}
+	//...but targets below /home are still owned by the respective users
+	if actual := ownerOfPath("home/alice/.profile", homeDirectories); actual != "alice" {
+		t.Errorf("expected owner of home/alice/.profile to be \"alice\", got %q", actual)
+	}
+}
@@ -0,0 +1,145 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/holocm/holo/cmd/holo-files/internal/common"
+	"github.com/holocm/holo/internal/pluginapi"
+)
+
+// journalVersion is a version of an entity that is recorded in a journal
+// entry.
+type journalVersion struct {
+	Name string // file name of the copy in the journal entry
+	Path string // path of the original
+}
+
+// journalVersions returns all versions of this entity that are recorded in a
+// journal entry.
+func (entity *Entity) journalVersions() []journalVersion {
+	return []journalVersion{
+		{"target", entity.PathIn(common.TargetDirectory())},
+		{"base", entity.PathIn(common.BaseDirectory())},
+		{"provisioned", entity.PathIn(common.ProvisionedDirectory())},
+	}
+}
+
+// saveToJournal records the current versions of this entity in the journal
+// directory given by Holo, such that `holo rollback` can restore them later.
+// If Holo did not give a journal directory, nothing is done.
+func (entity *Entity) saveToJournal() error {
+	entryPath := pluginapi.JournalEntryPathForPlugin(entity.EntityID())
+	if entryPath == "" {
+		return nil
+	}
+	err := os.MkdirAll(entryPath, 0700)
+	if err != nil {
+		return err
+	}
+
+	for _, version := range entity.journalVersions() {
+		buf, err := common.NewFileBuffer(version.Path)
+		if err != nil {
+			//unmanageable files are not touched by Apply() anyway
+			if os.IsNotExist(err) || errors.Is(err, common.ErrNotManageable) {
+				continue
+			}
+			return err
+		}
+		err = buf.Write(filepath.Join(entryPath, version.Name))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// discardJournal removes the journal entry for this entity again (when it
+// turns out that the entity was not changed after all).
+func (entity *Entity) discardJournal() error {
+	entryPath := pluginapi.JournalEntryPathForPlugin(entity.EntityID())
+	if entryPath == "" {
+		return nil
+	}
+	return os.RemoveAll(entryPath)
+}
+
+// Rollback restores the versions of this entity that were recorded in the
+// journal directory given by Holo. Versions that did not exist at that point
+// are deleted. The skipReport return value is true if nothing needed to be
+// changed.
+func (entity *Entity) Rollback() (skipReport bool, err error) {
+	entryPath := pluginapi.JournalEntryPathForPlugin(entity.EntityID())
+	if entryPath == "" {
+		return false, errors.New("no journal directory given")
+	}
+	_, err = os.Stat(entryPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, fmt.Errorf("no journal entry for %s", entity.EntityID())
+		}
+		return false, err
+	}
+
+	changed := false
+	for _, version := range entity.journalVersions() {
+		current, err := common.NewFileBuffer(version.Path)
+		if err != nil && !os.IsNotExist(err) {
+			return false, err
+		}
+
+		prior, err := common.NewFileBuffer(filepath.Join(entryPath, version.Name))
+		if err != nil {
+			if !os.IsNotExist(err) {
+				return false, err
+			}
+			//this version did not exist before
+			if current.Manageable {
+				err := os.Remove(version.Path)
+				if err != nil {
+					return false, err
+				}
+				changed = true
+			}
+			continue
+		}
+
+		if current.Manageable && current.EqualTo(prior) {
+			continue
+		}
+		err = os.MkdirAll(filepath.Dir(version.Path), 0755)
+		if err != nil {
+			return false, err
+		}
+		err = prior.Write(version.Path)
+		if err != nil {
+			return false, err
+		}
+		changed = true
+	}
+
+	return !changed, nil
+}
@@ -23,13 +23,16 @@ package impl
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/holocm/holo/cmd/holo-files/internal/common"
+	"github.com/holocm/holo/internal/procgroup"
 )
 
 // Resource represents a single file in $HOLO_RESOURCE_DIR. The string
@@ -75,6 +78,19 @@ func (resource Resource) Disambiguator() string {
 	return segments[0]
 }
 
+// Tag returns the tag that this resource contributes to its entity. It is
+// the Disambiguator() without its ordering prefix, e.g. "nginx" for
+// "20-nginx". A disambiguator that consists only of the ordering prefix (e.g.
+// "20") is used as a tag verbatim.
+func (resource Resource) Tag() string {
+	disambiguator := resource.Disambiguator()
+	idx := strings.IndexFunc(disambiguator, func(r rune) bool { return r < '0' || r > '9' })
+	if idx > 0 && disambiguator[idx] == '-' && idx+1 < len(disambiguator) {
+		return disambiguator[idx+1:]
+	}
+	return disambiguator
+}
+
 // ApplicationStrategy returns the human-readable name for the strategy that
 // will be employed to apply this repo file.
 func (resource Resource) ApplicationStrategy() string {
@@ -120,12 +136,23 @@ func (resource Resource) ApplyTo(entityBuffer common.FileBuffer) (common.FileBuf
 
 	//run command, fetch result file into buffer (not into the entity
 	//directly, in order not to corrupt the file there if the script run fails)
+	//
+	//The holoscript runs in its own process group, so that it can be killed
+	//reliably when it exceeds the timeout given by Holo.
 	var stdout bytes.Buffer
 	cmd := exec.Command(resource.Path())
 	cmd.Stdin = strings.NewReader(entityBuffer.Contents)
 	cmd.Stdout = &stdout
 	cmd.Stderr = os.Stderr
-	err = cmd.Run()
+	ctx, cancel, err := holoscriptContext()
+	if err != nil {
+		return common.FileBuffer{}, err
+	}
+	defer cancel()
+	err = procgroup.Run(ctx, cmd)
+	if err == context.DeadlineExceeded {
+		return common.FileBuffer{}, fmt.Errorf("execution of %s timed out after %s", resource.Path(), os.Getenv("HOLO_HOLOSCRIPT_TIMEOUT"))
+	}
 	if err != nil {
 		return common.FileBuffer{}, fmt.Errorf("execution of %s failed: %s", resource.Path(), err.Error())
 	}
@@ -136,6 +163,22 @@ func (resource Resource) ApplyTo(entityBuffer common.FileBuffer) (common.FileBuf
 	return entityBuffer, nil
 }
 
+// holoscriptContext returns the context for running a holoscript, which
+// observes the timeout from $HOLO_HOLOSCRIPT_TIMEOUT (if any).
+func holoscriptContext() (context.Context, context.CancelFunc, error) {
+	value := os.Getenv("HOLO_HOLOSCRIPT_TIMEOUT")
+	if value == "" {
+		ctx, cancel := context.WithCancel(context.Background())
+		return ctx, cancel, nil
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid value for $HOLO_HOLOSCRIPT_TIMEOUT: %s", err.Error())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	return ctx, cancel, nil
+}
+
 // Resources holds a slice of Resource instances, and implements some methods
 // to satisfy the sort.Interface interface.
 type Resources []Resource
@@ -95,9 +95,12 @@ func Scan() []*Entity {
 		return nil
 	})
 
-	//flatten result into list
+	//flatten result into list (and find the users owning the targets in
+	//their home directories, since these users need to exist first)
+	homeDirectories := readHomeDirectories(common.TargetDirectory())
 	result := make([]*Entity, 0, len(entities))
 	for _, entity := range entities {
+		entity.owner = ownerOfPath(entity.relPath, homeDirectories)
 		result = append(result, entity)
 	}
 
@@ -33,6 +33,11 @@ func (p apkImpl) FindUpdatedTargetBase(targetPath string) (actualPath, reportedP
 	return "", "", nil
 }
 
+func (p apkImpl) PeekUpdatedTargetBase(targetPath string) (actualPath, currentPath, reportedPath string) {
+	actualPath, reportedPath, _ = p.FindUpdatedTargetBase(targetPath)
+	return actualPath, targetPath, reportedPath
+}
+
 func (p apkImpl) AdditionalCleanupTargets(targetPath string) (ret []string) {
 	return nil
 }
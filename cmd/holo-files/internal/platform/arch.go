@@ -40,6 +40,11 @@ func (p archImpl) FindUpdatedTargetBase(targetPath string) (actualPath, reported
 	return "", "", nil
 }
 
+func (p archImpl) PeekUpdatedTargetBase(targetPath string) (actualPath, currentPath, reportedPath string) {
+	actualPath, reportedPath, _ = p.FindUpdatedTargetBase(targetPath)
+	return actualPath, targetPath, reportedPath
+}
+
 func (p archImpl) AdditionalCleanupTargets(targetPath string) (ret []string) {
 	pacsavePath := targetPath + ".pacsave"
 	if fs.IsManageableFile(pacsavePath) {
@@ -23,14 +23,12 @@
 package platform
 
 import (
-	"fmt"
-	"os"
-	"path/filepath"
-	"regexp"
 	"sort"
 	"strings"
 
 	"github.com/holocm/holo/cmd/holo-files/internal/common"
+	"github.com/holocm/holo/internal/osrelease"
+	"github.com/holocm/holo/internal/pluginapi"
 )
 
 // Impl provides integration points with a distribution's toolchain.
@@ -47,6 +45,12 @@ type Impl interface {
 	//is the original path to the updated target base, and the actualPath is
 	//where Holo will find the file.
 	FindUpdatedTargetBase(targetPath string) (actualPath, reportedPath string, err error)
+	//PeekUpdatedTargetBase is the read-only counterpart of
+	//FindUpdatedTargetBase that is used when planning changes. It does not
+	//move any files around, but reports where the updated target base and the
+	//current target can be found after FindUpdatedTargetBase would have done
+	//so. The currentPath is usually the same as the targetPath.
+	PeekUpdatedTargetBase(targetPath string) (actualPath, currentPath, reportedPath string)
 	//AdditionalCleanupTargets is called as part of the orphan handling. When
 	//an application package is removed, but one of its configuration files has
 	//been modified by Holo, the system package manager will usually retain a
@@ -85,55 +89,12 @@ func Implementation() Impl {
 // GetCurrentDistribution returns a set of distribution IDs, drawing on the ID=
 // and ID_LIKE= fields of os-release(5).
 func GetCurrentDistribution() map[string]bool {
-	//read /etc/os-release, fall back to /usr/lib/os-release if not available
-	bytes, err := os.ReadFile(filepath.Join(common.TargetDirectory(), "etc/os-release"))
+	variables, err := osrelease.Read(common.TargetDirectory())
 	if err != nil {
-		if os.IsNotExist(err) {
-			bytes, err = os.ReadFile(filepath.Join(common.TargetDirectory(), "usr/lib/os-release"))
-		}
-	}
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "!! Cannot read os-release(5): %v\n", err)
+		pluginapi.Errorf("Cannot read os-release(5): %v", err)
 		return nil
 	}
-
-	//parse os-release syntax (a harshly limited subset of shell script)
-	variables := make(map[string]string)
-	lines := strings.Split(string(bytes), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		//ignore comments
-		if line == "" || line[0] == '#' {
-			continue
-		}
-		//line format is key=value
-		if !strings.Contains(line, "=") {
-			continue
-		}
-		split := strings.SplitN(line, "=", 2)
-		key, value := split[0], split[1]
-		//value may be enclosed in quotes
-		switch {
-		case strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\""):
-			value = strings.TrimPrefix(strings.TrimSuffix(value, "\""), "\"")
-		case strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'"):
-			value = strings.TrimPrefix(strings.TrimSuffix(value, "'"), "'")
-		}
-		//special characters may be escaped
-		value = regexp.MustCompile(`\\(.)`).ReplaceAllString(value, "$1")
-		//store assignment
-		variables[key] = value
-	}
-
-	//the distribution IDs we're looking for are in ID= (single value) or ID_LIKE= (space-separated list)
-	result := map[string]bool{variables["ID"]: true}
-	if idLike, ok := variables["ID_LIKE"]; ok {
-		ids := strings.Split(idLike, " ")
-		for _, id := range ids {
-			result[id] = true
-		}
-	}
-	return result
+	return osrelease.DistributionIDs(variables)
 }
 
 // ReportUnsupportedDistribution prints the standard warning that the current
@@ -144,7 +105,7 @@ func ReportUnsupportedDistribution(isDist map[string]bool) {
 		dists = append(dists, dist)
 	}
 	sort.Strings(dists)
-	fmt.Fprintf(os.Stderr, "!! Running on an unrecognized distribution. Distribution IDs: %s\n", strings.Join(dists, ","))
-	fmt.Fprintf(os.Stderr, ">> Please report this error at <https://github.com/holocm/holo/issues/new>\n")
-	fmt.Fprintf(os.Stderr, ">> and include the contents of your /etc/os-release file.\n")
+	pluginapi.Errorf("Running on an unrecognized distribution. Distribution IDs: %s", strings.Join(dists, ","))
+	pluginapi.Warnf("Please report this error at <https://github.com/holocm/holo/issues/new>")
+	pluginapi.Warnf("and include the contents of your /etc/os-release file.")
 }
@@ -54,6 +54,24 @@ func (p dpkgImpl) FindUpdatedTargetBase(targetPath string) (actualPath, reported
 	return "", "", nil
 }
 
+func (p dpkgImpl) PeekUpdatedTargetBase(targetPath string) (actualPath, currentPath, reportedPath string) {
+	dpkgDistPath := targetPath + ".dpkg-dist"
+	dpkgOldPath := targetPath + ".dpkg-old"
+
+	//with "${target}.dpkg-old", FindUpdatedTargetBase() would swap files
+	//around such that the updated target base (currently at $target) ends up
+	//at "${target}.dpkg-dist", and the current target (currently at
+	//"${target}.dpkg-old") ends up at $target
+	if fs.IsManageableFile(dpkgOldPath) {
+		return targetPath, dpkgOldPath, fmt.Sprintf("%s (with .dpkg-old)", targetPath)
+	}
+
+	if fs.IsManageableFile(dpkgDistPath) {
+		return dpkgDistPath, targetPath, dpkgDistPath
+	}
+	return "", targetPath, ""
+}
+
 func (p dpkgImpl) AdditionalCleanupTargets(targetPath string) []string {
 	//not used by dpkg
 	return []string{}
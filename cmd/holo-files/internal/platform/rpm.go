@@ -54,6 +54,24 @@ func (p rpmImpl) FindUpdatedTargetBase(targetPath string) (actualPath, reportedP
 	return "", "", nil
 }
 
+func (p rpmImpl) PeekUpdatedTargetBase(targetPath string) (actualPath, currentPath, reportedPath string) {
+	rpmnewPath := targetPath + ".rpmnew"
+	rpmsavePath := targetPath + ".rpmsave"
+
+	//with "${target}.rpmsave", FindUpdatedTargetBase() would swap files
+	//around such that the updated target base (currently at $target) ends up
+	//at "${target}.rpmnew", and the current target (currently at
+	//"${target}.rpmsave") ends up at $target
+	if fs.IsManageableFile(rpmsavePath) {
+		return targetPath, rpmsavePath, fmt.Sprintf("%s (with .rpmsave)", targetPath)
+	}
+
+	if fs.IsManageableFile(rpmnewPath) {
+		return rpmnewPath, targetPath, rpmnewPath
+	}
+	return "", targetPath, ""
+}
+
 func (p rpmImpl) AdditionalCleanupTargets(targetPath string) []string {
 	//not used by RPM
 	return []string{}
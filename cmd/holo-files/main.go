@@ -23,9 +23,11 @@ package entrypoint
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/holocm/holo/cmd/holo-files/internal/common"
 	"github.com/holocm/holo/cmd/holo-files/internal/impl"
+	"github.com/holocm/holo/internal/pluginapi"
 )
 
 // Main is the main entry point, but returns the exit code rather than
@@ -34,19 +36,47 @@ import (
 func Main() (exitCode int) {
 	//the "info" action does not require any scanning
 	if os.Args[1] == "info" {
-		os.Stdout.Write([]byte("MIN_API_VERSION=3\nMAX_API_VERSION=3\n"))
+		os.Stdout.Write([]byte("MIN_API_VERSION=3\nMAX_API_VERSION=4\nOPTIONAL_OPERATIONS=plan rollback check explain\nVERSION=" + pluginapi.Version + "\nDESCRIPTION=Provisions configuration files from /usr/share/holo/files\nENTITY_PREFIXES=file:\n"))
 		return 0
 	}
 
+	//in a session, the scan only needs to be done once
+	if os.Args[1] == "serve" {
+		var entities []*impl.Entity
+		return pluginapi.Serve(func(args []string) int {
+			//the "rollback" action does not require any scanning either
+			if args[0] == "rollback" {
+				return rollbackEntity(args[1])
+			}
+			if entities == nil {
+				entities = impl.Scan()
+				if entities == nil {
+					return 1
+				}
+			}
+			return execute(entities, args)
+		})
+	}
+
+	//the "rollback" action does not require any scanning either (the entity
+	//might not even exist anymore in the current scan)
+	if os.Args[1] == "rollback" {
+		return rollbackEntity(os.Args[2])
+	}
+
 	//scan for entities
 	entities := impl.Scan()
 	if entities == nil {
 		//some fatal error occurred - it was already reported, so just exit
 		return 1
 	}
+	return execute(entities, os.Args[1:])
+}
 
+// execute runs a single operation, e.g. args = ["apply", "file:/etc/foo.conf"].
+func execute(entities []*impl.Entity, args []string) (exitCode int) {
 	//scan action requires no arguments
-	if os.Args[1] == "scan" {
+	if args[0] == "scan" {
 		for _, entity := range entities {
 			entity.PrintReport()
 		}
@@ -54,7 +84,7 @@ func Main() (exitCode int) {
 	}
 
 	//all other actions require an entity selection
-	entityID := os.Args[2]
+	entityID := args[1]
 	var selectedEntity *impl.Entity
 	for _, entity := range entities {
 		if entity.EntityID() == entityID {
@@ -63,50 +93,124 @@ func Main() (exitCode int) {
 		}
 	}
 	if selectedEntity == nil {
-		fmt.Fprintf(os.Stderr, "!! unknown entity ID \"%s\"\n", entityID)
+		pluginapi.Errorf("unknown entity ID \"%s\"", entityID)
 		return 1
 	}
 
-	switch os.Args[1] {
+	switch args[0] {
 	case "apply":
-		applyEntity(selectedEntity, false)
+		return applyEntity(selectedEntity, false, false)
 	case "force-apply":
-		applyEntity(selectedEntity, true)
+		return applyEntity(selectedEntity, true, false)
+	case "plan":
+		return applyEntity(selectedEntity, false, true)
+	case "force-plan":
+		return applyEntity(selectedEntity, true, true)
+	case "check":
+		status, err := selectedEntity.Check()
+		if err != nil {
+			pluginapi.Errorf(err.Error())
+			return 1
+		}
+		_, err = pluginapi.Messages().Write([]byte(status + "\n"))
+		if err != nil {
+			pluginapi.Errorf(err.Error())
+		}
 	case "diff":
 		output := fmt.Sprintf("%s\000%s\000",
 			selectedEntity.PathIn(common.ProvisionedDirectory()),
 			selectedEntity.PathIn(common.TargetDirectory()),
 		)
-		_, err := os.NewFile(3, "file descriptor 3").Write([]byte(output))
+		//further arguments request other versions of the entity
+		for _, version := range args[2:] {
+			path, err := selectedEntity.DiffVersion(version)
+			if err != nil {
+				pluginapi.Errorf("cannot compute %s version: %s", version, err.Error())
+				return 1
+			}
+			output += fmt.Sprintf("%s\000%s\000", version, path)
+		}
+		_, err := pluginapi.Messages().Write([]byte(output))
+		if err != nil {
+			pluginapi.Errorf(err.Error())
+		}
+	case "explain":
+		steps, err := selectedEntity.Explain()
+		if err != nil {
+			pluginapi.Errorf(err.Error())
+			return 1
+		}
+		output := ""
+		for _, step := range steps {
+			output += fmt.Sprintf("%s\000%s\000%s\000", step.Strategy, step.Source, step.Result)
+		}
+		_, err = pluginapi.Messages().Write([]byte(output))
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "!! %s\n", err.Error())
+			pluginapi.Errorf(err.Error())
 		}
 	}
 
 	return 0
 }
 
-func applyEntity(entity *impl.Entity, withForce bool) {
-	skipReport, needForceToOverwrite, needForceToRestore := entity.Apply(withForce)
+func applyEntity(entity *impl.Entity, withForce, dryRun bool) (exitCode int) {
+	targetPath := entity.PathIn(common.TargetDirectory())
+	stateBefore := pluginapi.HashFile(targetPath)
+	skipReport, needForceToOverwrite, needForceToRestore, failed := entity.Apply(withForce, dryRun)
+	if failed {
+		exitCode = 1
+	}
+
+	//report the state hashes for Holo's history
+	if !dryRun && !skipReport && !failed && !needForceToOverwrite && !needForceToRestore {
+		err := pluginapi.ReportStateHashes(stateBefore, pluginapi.HashFile(targetPath))
+		if err != nil {
+			pluginapi.Errorf(err.Error())
+		}
+	}
 
 	if skipReport {
-		_, err := os.NewFile(3, "file descriptor 3").Write([]byte("not changed\n"))
+		_, err := pluginapi.Messages().Write([]byte("not changed\n"))
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "!! %s\n", err.Error())
+			pluginapi.Errorf(err.Error())
 		}
 	}
 
 	if needForceToOverwrite {
-		_, err := os.NewFile(3, "file descriptor 3").Write([]byte("requires --force to overwrite\n"))
+		_, err := pluginapi.Messages().Write([]byte("requires --force to overwrite\n"))
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "!! %s\n", err.Error())
+			pluginapi.Errorf(err.Error())
 		}
 	}
 
 	if needForceToRestore {
-		_, err := os.NewFile(3, "file descriptor 3").Write([]byte("requires --force to restore\n"))
+		_, err := pluginapi.Messages().Write([]byte("requires --force to restore\n"))
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "!! %s\n", err.Error())
+			pluginapi.Errorf(err.Error())
 		}
 	}
+
+	return exitCode
+}
+
+func rollbackEntity(entityID string) (exitCode int) {
+	if !strings.HasPrefix(entityID, "file:/") {
+		pluginapi.Errorf("unknown entity ID \"%s\"", entityID)
+		return 1
+	}
+	entity := impl.NewEntity(strings.TrimPrefix(entityID, "file:/"))
+
+	skipReport, err := entity.Rollback()
+	if err != nil {
+		pluginapi.Errorf(err.Error())
+		return 1
+	}
+
+	if skipReport {
+		_, err := pluginapi.Messages().Write([]byte("not changed\n"))
+		if err != nil {
+			pluginapi.Errorf(err.Error())
+		}
+	}
+	return 0
 }
@@ -25,6 +25,8 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+
+	"github.com/holocm/holo/internal/pluginapi"
 )
 
 // Entity represents a key file in the source directory, and the keys
@@ -89,20 +91,133 @@ func (e *Entity) Keys() ([]*Key, error) {
 	return result, err
 }
 
-// Apply applies this entity.
-func (e *Entity) Apply() error {
+// Apply applies this entity. With dryRun, the authorized_keys file is not
+// touched. Instead, the changes that would be made are described on stdout.
+//
+// Unless dryRun is set, the prior state of the entity is recorded in the
+// journal directory given by Holo if the entity is changed, and the hashes of
+// its state before and after the change are reported to Holo.
+func (e *Entity) Apply(dryRun bool) error {
 	//get User instance (to locate the authorized_keys file)
 	user, err := NewUser(e.UserName)
 	if err != nil {
 		return err
 	}
 
-	//list keys in this entity's key file, setup data structure for tracking
-	//them during the traversal of authorized_keys
+	//list keys in this entity's key file
 	keys, err := e.Keys()
 	if err != nil {
 		return err
 	}
+
+	var stateBefore string
+	if !dryRun {
+		err = e.saveToJournal(user.KeyFile())
+		if err != nil {
+			return fmt.Errorf("cannot record prior state in journal: %s", err.Error())
+		}
+		stateBefore = e.stateHash()
+	}
+
+	changed, err := e.provisionKeys(user, keys, dryRun)
+	if err != nil {
+		return err
+	}
+
+	//report whether entity was changed
+	if !changed {
+		if !dryRun {
+			err := e.discardJournal()
+			if err != nil {
+				return err
+			}
+		}
+		_, err := pluginapi.Messages().Write([]byte("not changed\n"))
+		return err
+	}
+	if !dryRun {
+		return pluginapi.ReportStateHashes(stateBefore, e.stateHash())
+	}
+	return nil
+}
+
+// stateHash returns a hash of the section of the authorized_keys file that
+// belongs to this entity (as shown by the "diff" operation), or an empty
+// string if it cannot be determined.
+func (e *Entity) stateHash() string {
+	_, actualPath, err := e.PrepareDiff()
+	if err != nil {
+		return ""
+	}
+	return pluginapi.HashFile(actualPath)
+}
+
+// Rollback restores the keys for this entity that were recorded in the journal
+// directory given by Holo.
+func (e *Entity) Rollback() error {
+	//get User instance (to locate the authorized_keys file)
+	user, err := NewUser(e.UserName)
+	if err != nil {
+		return err
+	}
+
+	keys, err := e.loadFromJournal()
+	if err != nil {
+		return err
+	}
+	changed, err := e.provisionKeys(user, keys, false)
+	if err != nil {
+		return err
+	}
+
+	//report whether entity was changed
+	if !changed {
+		_, err := pluginapi.Messages().Write([]byte("not changed\n"))
+		return err
+	}
+	return nil
+}
+
+// provisionKeys makes sure that exactly the given keys are provisioned for
+// this entity in the user's authorized_keys file. With dryRun, the
+// authorized_keys file is not touched. Instead, the changes that would be
+// made are described on stdout.
+func (e *Entity) provisionKeys(user *User, keys []*Key, dryRun bool) (changed bool, err error) {
+	keyCallback, endCallback := e.keyCallbacks(keys)
+	keyFile := user.KeyFile()
+	if dryRun {
+		removed, added, err := plannedChanges(keyFile, keyCallback, endCallback)
+		if err != nil {
+			return false, err
+		}
+		for _, key := range removed {
+			fmt.Printf("remove from %s: %s\n", string(keyFile), key.String())
+		}
+		for _, key := range added {
+			fmt.Printf("add to %s: %s\n", string(keyFile), key.String())
+		}
+		return len(removed)+len(added) > 0, nil
+	}
+
+	changed, err = keyFile.Process(keyCallback, endCallback)
+	if err != nil {
+		return false, err
+	}
+	err = user.CheckPermissions()
+	if err != nil {
+		return false, err
+	}
+
+	//record whether there are keys provisioned for this user
+	SetEntityProvisioned(e.Name, len(keys) > 0)
+	return changed, nil
+}
+
+// keyCallbacks returns the callbacks for KeyFile.Process() that make sure that
+// exactly the given keys are provisioned for this entity.
+func (e *Entity) keyCallbacks(keys []*Key) (keyCallback func(*Key) *Key, endCallback func() []*Key) {
+	//setup data structure for tracking keys during the traversal of
+	//authorized_keys
 	isKnownKey := make(map[string]*Key)
 	for _, key := range keys {
 		isKnownKey[key.Identifier()] = key
@@ -110,7 +225,7 @@ func (e *Entity) Apply() error {
 
 	//process authorized_keys file
 	keyComment := "holo=" + e.Name
-	keyCallback := func(key *Key) *Key {
+	keyCallback = func(key *Key) *Key {
 		//ignore all keys not belonging to this entity
 		if key.Comment != keyComment {
 			return key
@@ -124,7 +239,7 @@ func (e *Entity) Apply() error {
 		delete(isKnownKey, id)
 		return key
 	}
-	endCallback := func() []*Key {
+	endCallback = func() []*Key {
 		//all the keys remaining in isKnownKey are new and we add them now
 		result := make([]*Key, 0, len(isKnownKey))
 		for _, key := range keys {
@@ -135,24 +250,57 @@ func (e *Entity) Apply() error {
 		}
 		return result
 	}
-	changed, err := user.KeyFile().Process(keyCallback, endCallback)
+	return keyCallback, endCallback
+}
+
+// plannedChanges lists the keys that provisionKeys would remove from or add to
+// the given authorized_keys file, without touching the file.
+func plannedChanges(keyFile KeyFile, keyCallback func(*Key) *Key, endCallback func() []*Key) (removed, added []*Key, err error) {
+	err = keyFile.Walk(func(key *Key) {
+		if keyCallback(key) == nil {
+			removed = append(removed, key)
+		}
+	})
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
-	err = user.CheckPermissions()
+	return removed, endCallback(), nil
+}
+
+// Check reports whether the keys provisioned for this entity match its key
+// file, as one of the statuses "in sync", "pending" or "orphaned". Since
+// nothing else manages keys tagged with this entity's name, the keys never
+// count as "drifted"; a user modification is simply reverted by the next
+// apply.
+func (e *Entity) Check() (string, error) {
+	//get User instance (to locate the authorized_keys file)
+	user, err := NewUser(e.UserName)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	//record whether there are keys provisioned for this user
-	SetEntityProvisioned(e.Name, len(keys) > 0)
+	//an entity without key file is orphaned if it still has keys provisioned
+	//(this is how Scan() finds orphaned entities)
+	keys, err := e.Keys()
+	if err != nil {
+		return "", err
+	}
+	_, err = os.Stat(e.FilePath)
+	orphaned := os.IsNotExist(err)
 
-	//report whether entity was changed
-	if !changed {
-		_, err := os.NewFile(3, "file descriptor 3").Write([]byte("not changed\n"))
-		return err
+	keyCallback, endCallback := e.keyCallbacks(keys)
+	removed, added, err := plannedChanges(user.KeyFile(), keyCallback, endCallback)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case orphaned:
+		return "orphaned", nil
+	case len(removed)+len(added) > 0:
+		return "pending", nil
+	default:
+		return "in sync", nil
 	}
-	return nil
 }
 
 // PrepareDiff creates temporary files that the frontend can use to generate a
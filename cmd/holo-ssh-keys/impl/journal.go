@@ -0,0 +1,104 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/holocm/holo/internal/pluginapi"
+)
+
+// saveToJournal records the keys that are currently provisioned for this
+// entity in the given authorized_keys file in the journal directory given by
+// Holo, such that `holo rollback` can restore them later. If Holo did not give
+// a journal directory, nothing is done.
+func (e *Entity) saveToJournal(keyFile KeyFile) error {
+	entryPath := pluginapi.JournalEntryPathForPlugin(e.Name)
+	if entryPath == "" {
+		return nil
+	}
+
+	keyComment := "holo=" + e.Name
+	var provisionedKeys []*Key
+	err := keyFile.Walk(func(key *Key) {
+		if key.Comment == keyComment {
+			provisionedKeys = append(provisionedKeys, key)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	//the journal entry is itself a key file (an empty one if no keys were
+	//provisioned)
+	err = os.MkdirAll(filepath.Dir(entryPath), 0700)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(entryPath, nil, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = KeyFile(entryPath).Process(
+		func(key *Key) *Key { return key },
+		func() []*Key { return provisionedKeys },
+	)
+	return err
+}
+
+// discardJournal removes the journal entry for this entity again (when it
+// turns out that the entity was not changed after all).
+func (e *Entity) discardJournal() error {
+	entryPath := pluginapi.JournalEntryPathForPlugin(e.Name)
+	if entryPath == "" {
+		return nil
+	}
+	err := os.Remove(entryPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// loadFromJournal returns the keys for this entity that were recorded in the
+// journal directory given by Holo.
+func (e *Entity) loadFromJournal() ([]*Key, error) {
+	entryPath := pluginapi.JournalEntryPathForPlugin(e.Name)
+	if entryPath == "" {
+		return nil, errors.New("no journal directory given")
+	}
+	_, err := os.Stat(entryPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no journal entry for %s", e.Name)
+		}
+		return nil, err
+	}
+
+	var keys []*Key
+	err = KeyFile(entryPath).Walk(func(key *Key) {
+		keys = append(keys, key)
+	})
+	return keys, err
+}
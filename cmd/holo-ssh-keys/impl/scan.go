@@ -114,6 +114,15 @@ func scanDirectory(path string, entityNameWasSeen *map[string]bool) []error {
 			//report entity
 			fmt.Printf("ENTITY: %s\n", entity.Name)
 			fmt.Printf("SOURCE: %s\n", entity.FilePath)
+			//keysets with the same file name (e.g. "deploy.pub" for several
+			//users) can be selected together with "tag:deploy"
+			fmt.Printf("TAG: %s\n", entity.BaseName)
+			//if the user is provisioned by holo-users-groups, it must be created first
+			fmt.Printf("REQUIRES: user:%s\n", entity.UserName)
+			//(if the user does not exist yet, there is no authorized_keys file to watch)
+			if user, err := NewUser(entity.UserName); err == nil {
+				fmt.Printf("WATCH: %s\n", string(user.KeyFile()))
+			}
 			fmt.Printf("found in: %s\n", entity.FilePath)
 			if len(fingerprints) == 0 {
 				fmt.Println("is: empty!")
@@ -24,9 +24,15 @@ package impl
 //#include <pwd.h>
 import "C"
 import (
+	"bufio"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/holocm/holo/internal/pluginapi"
 )
 
 // User represents a user account on the system. The methods on this struct
@@ -38,14 +44,24 @@ type User struct {
 	GID  int
 }
 
-var rootDir = os.Getenv("HOLO_ROOT_DIR") //determines whether we run in unit-test mode
+var rootDir = os.Getenv("HOLO_ROOT_DIR")
+
+func init() {
+	if rootDir == "" {
+		rootDir = "/"
+	}
+}
 
 // NewUser returns the User with the given name.
 func NewUser(name string) (*User, error) {
-	if rootDir == "/" {
+	switch {
+	case pluginapi.IsTestMode():
+		return newUserMock(name)
+	case rootDir == "/":
 		return newUserActual(name)
+	default:
+		return newUserInRoot(name)
 	}
-	return newUserMock(name)
 }
 
 func newUserActual(name string) (*User, error) {
@@ -62,6 +78,44 @@ func newUserActual(name string) (*User, error) {
 	}, nil
 }
 
+func newUserInRoot(name string) (*User, error) {
+	//when provisioning an alternate root, getpwnam() would look at the wrong
+	//user database, so read the passwd file of the alternate root instead
+	passwdPath := filepath.Join(rootDir, "etc/passwd")
+	file, err := os.Open(passwdPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		//format: name:password:UID:GID:GECOS:home:shell
+		fields := strings.Split(scanner.Text(), ":")
+		if len(fields) < 6 || fields[0] != name {
+			continue
+		}
+		uid, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid UID for user %s in %s: %s", name, passwdPath, err.Error())
+		}
+		gid, err := strconv.Atoi(fields[3])
+		if err != nil {
+			return nil, fmt.Errorf("invalid GID for user %s in %s: %s", name, passwdPath, err.Error())
+		}
+		return &User{
+			Name: name,
+			Home: filepath.Join(rootDir, fields[5]),
+			UID:  uid,
+			GID:  gid,
+		}, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("no such user in %s: %s", passwdPath, name)
+}
+
 func newUserMock(name string) (*User, error) {
 	//in testing mode, don't check the system user database;
 	//assume all users have /home/$username as home directory
@@ -94,7 +148,7 @@ func (u *User) CheckPermissions() error {
 	pathKeys := filepath.Join(pathDssh, "authorized_keys")
 
 	//no chown when running in test mode
-	if rootDir == "/" {
+	if !pluginapi.IsTestMode() {
 		err := os.Chown(pathHome, u.UID, u.GID)
 		if err != nil {
 			return err
@@ -0,0 +1,59 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+package impl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewUserInRoot(t *testing.T) {
+	dir := t.TempDir()
+	err := os.MkdirAll(filepath.Join(dir, "etc"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	passwd := "root:x:0:0::/root:/bin/bash\njohn:x:1000:100:John Doe:/srv/john:/bin/zsh\nbroken:x:abc:100::/home/broken:/bin/sh\n"
+	err = os.WriteFile(filepath.Join(dir, "etc/passwd"), []byte(passwd), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldRootDir := rootDir
+	rootDir = dir
+	defer func() { rootDir = oldRootDir }()
+
+	user, err := newUserInRoot("john")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := User{Name: "john", Home: filepath.Join(dir, "srv/john"), UID: 1000, GID: 100}
+	if *user != expected {
+		t.Errorf("expected %#v, got %#v", expected, *user)
+	}
+
+	for _, name := range []string{"jane", "broken"} {
+		user, err := newUserInRoot(name)
+		if err == nil {
+			t.Errorf("expected error for user %s, got %#v", name, user)
+		}
+	}
+}
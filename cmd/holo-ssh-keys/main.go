@@ -25,52 +25,88 @@ import (
 	"os"
 
 	"github.com/holocm/holo/cmd/holo-ssh-keys/impl"
+	"github.com/holocm/holo/internal/pluginapi"
 )
 
 // Main is the main entry point, but returns the exit code rather than
 // calling os.Exit().  This distinction is useful for monobinary and
 // testing purposes.
 func Main() (exitCode int) {
-	if version := os.Getenv("HOLO_API_VERSION"); version != "3" {
-		fmt.Fprintf(os.Stderr, "!! holo-users-groups plugin called with unknown HOLO_API_VERSION %s\n", version)
+	if version := os.Getenv("HOLO_API_VERSION"); version != "3" && version != "4" {
+		pluginapi.Errorf("holo-users-groups plugin called with unknown HOLO_API_VERSION %s", version)
 		return 1
 	}
 
-	//operations that do not require any arguments
 	switch os.Args[1] {
 	case "info":
-		os.Stdout.Write([]byte("MIN_API_VERSION=3\nMAX_API_VERSION=3\n"))
-		return
+		os.Stdout.Write([]byte("MIN_API_VERSION=3\nMAX_API_VERSION=4\nOPTIONAL_OPERATIONS=plan rollback check\nVERSION=" + pluginapi.Version + "\nDESCRIPTION=Provisions SSH public keys in authorized_keys files\nENTITY_PREFIXES=ssh-keyset:\n"))
+		return 0
+	case "serve":
+		return pluginapi.Serve(execute)
+	}
+	return execute(os.Args[1:])
+}
+
+// execute runs a single operation, e.g. args = ["apply", "ssh-keyset:john/login"].
+func execute(args []string) (exitCode int) {
+	//operations that do not require any arguments
+	switch args[0] {
 	case "scan":
 		errs := impl.Scan()
 		for _, err := range errs {
-			fmt.Fprintf(os.Stderr, "!! %s\n", err.Error())
+			pluginapi.Errorf(err.Error())
 		}
 		return
 	}
 
 	//all other operations work on an entity
-	entity, err := impl.NewEntityFromName(os.Args[2])
+	entity, err := impl.NewEntityFromName(args[1])
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "!! %s\n", err.Error())
+		pluginapi.Errorf(err.Error())
 		return 1
 	}
 
-	switch os.Args[1] {
-	case "apply", "force-apply":
-		err := entity.Apply()
+	switch args[0] {
+	case "apply", "force-apply", "plan", "force-plan":
+		dryRun := args[0] == "plan" || args[0] == "force-plan"
+		err := entity.Apply(dryRun)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "!! %s\n", err.Error())
+			pluginapi.Errorf(err.Error())
+			return 1
+		}
+	case "rollback":
+		err := entity.Rollback()
+		if err != nil {
+			pluginapi.Errorf(err.Error())
+			return 1
+		}
+	case "check":
+		status, err := entity.Check()
+		if err != nil {
+			pluginapi.Errorf(err.Error())
+			return 1
+		}
+		_, err = pluginapi.Messages().Write([]byte(status + "\n"))
+		if err != nil {
+			pluginapi.Errorf(err.Error())
+			return 1
 		}
 	case "diff":
 		expectedStateFile, actualStateFile, err := entity.PrepareDiff()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "!! %s\n", err.Error())
+			pluginapi.Errorf(err.Error())
 		}
 		out := fmt.Sprintf("%s\000%s\000", expectedStateFile, actualStateFile)
-		_, err = os.NewFile(3, "file descriptor 3").Write([]byte(out))
+		//of the other versions that Holo may request, only the desired
+		//version is known (the keyset is applied as-is)
+		for _, version := range args[2:] {
+			if version == "desired" {
+				out += fmt.Sprintf("desired\000%s\000", expectedStateFile)
+			}
+		}
+		_, err = pluginapi.Messages().Write([]byte(out))
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "!! %s\n", err.Error())
+			pluginapi.Errorf(err.Error())
 		}
 	}
 
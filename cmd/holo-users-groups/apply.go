@@ -27,6 +27,8 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+
+	"github.com/holocm/holo/internal/pluginapi"
 )
 
 // Apply implements the EntityDefinition interface.
@@ -112,11 +114,26 @@ func (u *UserDefinition) Cleanup() error {
 	return ExecProgramOrMock("userdel", u.Name)
 }
 
-// ExecProgramOrMock is a wrapper around exec.Command().Run() that, if run in a
-// test environment, only prints the command line instead of executing the
-// command.
+// DryRun is set for the "plan" and "force-plan" operations. When set, no
+// changes are made to the system or to the image directories.
+var DryRun bool
+
+// ExecProgramOrMock is a wrapper around exec.Command().Run() that, if run in
+// test mode, only prints the command line instead of executing the command. In
+// a dry run, the command line is printed as a planned change.
+//
+// When provisioning an alternate root, the command is instructed to work on
+// that root with the --root option that all shadow utilities understand. (In
+// test mode, the alternate root stands in for "/", so this is not done.)
 func ExecProgramOrMock(command string, arguments ...string) (err error) {
-	mock := os.Getenv("HOLO_ROOT_DIR") != "/"
+	mock := pluginapi.IsTestMode()
+	if !mock && rootDirectory != "/" {
+		arguments = append([]string{"--root", rootDirectory}, arguments...)
+	}
+	if DryRun {
+		fmt.Printf("run %s %s\n", command, shellEscapeArgs(arguments))
+		return nil
+	}
 	if mock {
 		fmt.Printf("MOCK: %s %s\n", command, shellEscapeArgs(arguments))
 		return nil
@@ -24,6 +24,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+
+	"github.com/holocm/holo/internal/pluginapi"
 )
 
 // Entity contains attributes and logic that are shared between entity types.
@@ -31,6 +33,20 @@ type Entity struct {
 	Definition      EntityDefinition
 	DefinitionFiles []string //paths to the files defining this entity
 	IsBroken        bool     //whether any of these are invalid (default: false)
+	Tags            []string //tags from all definitions, in order of appearance
+}
+
+// AddTags adds the given tags to this entity, skipping those that it already has.
+func (e *Entity) AddTags(tags ...string) {
+outer:
+	for _, tag := range tags {
+		for _, existing := range e.Tags {
+			if existing == tag {
+				continue outer
+			}
+		}
+		e.Tags = append(e.Tags, tag)
+	}
 }
 
 // IsOrphaned returns whether all definitions for this entity have been deleted.
@@ -48,15 +64,31 @@ func (e *Entity) PrintReport() {
 			fmt.Printf("found in: %s\n", defFile)
 			fmt.Printf("SOURCE: %s\n", defFile)
 		}
+		for _, tag := range e.Tags {
+			fmt.Printf("TAG: %s\n", tag)
+		}
 		if attributes := e.Definition.Attributes(); attributes != "" {
 			fmt.Printf("with: %s\n", attributes)
 		}
+		//groups must be created before the users that are members of them
+		if user, ok := e.Definition.(*UserDefinition); ok {
+			if user.Group != "" {
+				fmt.Printf("REQUIRES: group:%s\n", user.Group)
+			}
+			for _, group := range user.Groups {
+				fmt.Printf("REQUIRES: group:%s\n", group)
+			}
+			fmt.Printf("WATCH: %s\n", etcPasswdPath)
+		}
+		//(group memberships of users are also recorded in /etc/group)
+		fmt.Printf("WATCH: %s\n", etcGroupPath)
 	}
 }
 
 // Apply performs the complete application algorithm for the given Entity.
 // If the entity does not exist yet, it is created. If it does exist, but some
 // attributes do not match, it will be updated, but only if withForce is given.
+// If DryRun is set, the necessary changes are only described on stdout.
 func (e *Entity) Apply(withForce bool) error {
 	def := e.Definition
 
@@ -79,7 +111,7 @@ func (e *Entity) Apply(withForce bool) error {
 		} else {
 			err = def.Cleanup()
 		}
-		if err != nil {
+		if err != nil || DryRun {
 			return err
 		}
 
@@ -96,9 +128,11 @@ func (e *Entity) Apply(withForce bool) error {
 	if err != nil {
 		if os.IsNotExist(err) {
 			//write base image on first `apply`
-			err = BaseImageDir.SaveImage(actualState)
-			if err != nil {
-				return err
+			if !DryRun {
+				err = BaseImageDir.SaveImage(actualState)
+				if err != nil {
+					return err
+				}
 			}
 			baseImage = actualState
 		} else {
@@ -106,6 +140,61 @@ func (e *Entity) Apply(withForce bool) error {
 		}
 	}
 
+	//check if --force is required
+	desiredState, forceMessage, err := e.desiredState(actualState, baseImage)
+	if err != nil {
+		return err
+	}
+	if forceMessage != "" && !withForce {
+		PrintCommandMessage(forceMessage)
+		return nil
+	}
+
+	//check if changes are necessary
+	doNotApply := false
+	if actualState.IsProvisioned() {
+		actualStr, err := SerializeDefinition(actualState)
+		if err != nil {
+			return err
+		}
+		desiredStr, err := SerializeDefinition(desiredState)
+		if err != nil {
+			return err
+		}
+		if string(desiredStr) == string(actualStr) {
+			PrintCommandMessage("not changed\n")
+			doNotApply = true
+		}
+	}
+
+	//apply changes
+	if !doNotApply {
+		err = desiredState.Apply(actualState)
+		if err != nil {
+			return err
+		}
+		if DryRun {
+			return nil
+		}
+		StoreAppliedState(desiredState, actualState)
+	}
+	if DryRun {
+		return nil
+	}
+
+	//record new actual state as provisioned state
+	actualState, err = def.GetProvisionedState()
+	if err != nil {
+		return fmt.Errorf("cannot read %s database: %s", def.TypeName(), err.Error())
+	}
+	return ProvisionedImageDir.SaveImage(actualState)
+}
+
+// desiredState computes the desired state of this (non-orphaned) entity from
+// its actual state and its base image. If the entity has been modified or
+// deleted by the user since it was provisioned, the forceMessage is the
+// command message that signals that --force is required to apply it.
+func (e *Entity) desiredState(actualState, baseImage EntityDefinition) (desiredState EntityDefinition, forceMessage string, err error) {
 	//load last provisioned state (if not existing, use base image; and in this
 	//case, conflicts worthy of "--force" are detected by comparing that to the
 	//definition)
@@ -119,30 +208,27 @@ func (e *Entity) Apply(withForce bool) error {
 		if os.IsNotExist(err) {
 			provisionedState = nil
 		} else {
-			return err
+			return nil, "", err
 		}
 	}
 
 	//check if --force is required: either...
-	if !withForce {
-		//1. the entity has been provisioned and since been deleted
-		if provisionedState != nil && provisionedState.IsProvisioned() && !actualState.IsProvisioned() {
-			PrintCommandMessage("requires --force to restore\n")
-			return nil
-		}
+	//1. the entity has been provisioned and since been deleted
+	if provisionedState != nil && provisionedState.IsProvisioned() && !actualState.IsProvisioned() {
+		forceMessage = "requires --force to restore\n"
+	} else {
 		//2. the entity has been provisioned and since been altered (i.e. compare
 		//   actual state and provisioned state)
 		//3. the entity has *not* been provisioned yet and the definition conflicts
 		//   with the current state (i.e. the base image)
 		_, conflicts := actualState.Merge(conflictCheckImage, conflictCheckMethod, SkipEnabled)
 		if len(conflicts) > 0 {
-			PrintCommandMessage("requires --force to overwrite\n")
-			return nil
+			forceMessage = "requires --force to overwrite\n"
 		}
 	}
 
 	//desired state is obtained by merging the definition with the base image
-	desiredState, _ := e.Definition.Merge(baseImage, MergeWhereCompatible, SkipEnabled)
+	desiredState, _ = e.Definition.Merge(baseImage, MergeWhereCompatible, SkipEnabled)
 	//but make sure that we don't see a difference just because the definition
 	//does not define a particular attribute
 	desiredState, _ = desiredState.Merge(actualState, MergeEmptyOnly, SkipDisabled)
@@ -151,43 +237,105 @@ func (e *Entity) Apply(withForce bool) error {
 	if provisionedState != nil {
 		desiredState, _ = desiredState.Merge(provisionedState, MergeEmptyOnly, SkipDisabled)
 	}
+	return desiredState, forceMessage, nil
+}
 
-	//check if changes are necessary
-	doNotApply := false
-	if actualState.IsProvisioned() {
-		actualStr, err := SerializeDefinition(actualState)
-		if err != nil {
-			return err
-		}
-		desiredStr, err := SerializeDefinition(desiredState)
-		if err != nil {
-			return err
-		}
-		if string(desiredStr) == string(actualStr) {
-			PrintCommandMessage("not changed\n")
-			doNotApply = true
-		}
+// Check determines whether the entity is in its desired state without
+// changing anything. The returned status is one of the values defined for
+// the "check" operation in holo-plugin-interface(7): "in sync", "drifted",
+// "pending" or "orphaned".
+func (e *Entity) Check() (string, error) {
+	if e.IsOrphaned() {
+		return "orphaned", nil
 	}
 
-	//apply changes
-	if !doNotApply {
-		err = desiredState.Apply(actualState)
-		if err != nil {
-			return err
+	def := e.Definition
+	actualState, err := def.GetProvisionedState()
+	if err != nil {
+		return "", fmt.Errorf("cannot read %s database: %s", def.TypeName(), err.Error())
+	}
+	baseImage, err := BaseImageDir.LoadImageFor(def)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return "", err
 		}
-		StoreAppliedState(desiredState, actualState)
+		baseImage = actualState
 	}
 
-	//record new actual state as provisioned state
-	actualState, err = def.GetProvisionedState()
+	desiredState, forceMessage, err := e.desiredState(actualState, baseImage)
 	if err != nil {
-		return fmt.Errorf("cannot read %s database: %s", def.TypeName(), err.Error())
+		return "", err
 	}
-	return ProvisionedImageDir.SaveImage(actualState)
+	if forceMessage != "" {
+		return "drifted", nil
+	}
+	if !actualState.IsProvisioned() {
+		return "pending", nil
+	}
+	equal, err := definitionsEqual(actualState, desiredState)
+	if err != nil {
+		return "", err
+	}
+	if equal {
+		return "in sync", nil
+	}
+	return "pending", nil
+}
+
+// ApplyWithJournal is like Apply, but records the prior state of the entity
+// in the journal directory given by Holo (if any) when the entity is changed.
+// Also, the hashes of its state before and after are reported to Holo.
+func (e *Entity) ApplyWithJournal(withForce bool) error {
+	stateBefore := e.stateHash()
+	err := e.applyWithJournal(withForce)
+	if err != nil {
+		return err
+	}
+	return pluginapi.ReportStateHashes(stateBefore, e.stateHash())
+}
+
+func (e *Entity) applyWithJournal(withForce bool) error {
+	journal := journalEntryFor(e.Definition)
+	if journal == "" {
+		return e.Apply(withForce)
+	}
+	err := journal.Save(e.Definition)
+	if err != nil {
+		return fmt.Errorf("cannot record prior state in journal: %s", err.Error())
+	}
+
+	err = e.Apply(withForce)
+
+	//only keep the journal entry if anything was changed
+	unchanged, checkErr := journal.IsUnchanged(e.Definition)
+	if checkErr == nil && unchanged {
+		checkErr = journal.Discard()
+	}
+	if checkErr != nil && err == nil {
+		err = checkErr
+	}
+	return err
+}
+
+// stateHash returns a hash of the actual state of this entity (as shown by the
+// "diff" operation), or an empty string if it cannot be determined.
+func (e *Entity) stateHash() string {
+	actualState, err := e.Definition.GetProvisionedState()
+	if err != nil {
+		return ""
+	}
+	if !actualState.IsProvisioned() {
+		return "absent"
+	}
+	buf, err := SerializeDefinition(actualState)
+	if err != nil {
+		return ""
+	}
+	return pluginapi.HashBytes(buf)
 }
 
 // PrepareDiff creates temporary files that the frontend can use to generate a diff.
-func (e *Entity) PrepareDiff() error {
+func (e *Entity) PrepareDiff(versions []string) error {
 	//prepare directory to write files into
 	tempDir := filepath.Join(os.Getenv("HOLO_CACHE_DIR"), e.Definition.EntityID())
 	err := os.MkdirAll(tempDir, 0700)
@@ -227,16 +375,70 @@ func (e *Entity) PrepareDiff() error {
 	}
 
 	PrintCommandMessage("%s\000%s\000", desiredPath, actualPath)
+
+	//further versions may have been requested by Holo
+	for _, version := range versions {
+		path, err := e.diffVersion(version, actualState, tempDir)
+		if err != nil {
+			return err
+		}
+		PrintCommandMessage("%s\000%s\000", version, path)
+	}
 	return nil
 }
 
-// PrintCommandMessage formats and prints a message on file descriptor 3.
+// diffVersion writes the given version of this entity ("base", "provisioned",
+// "desired" or "current") into a file in tempDir for the "diff" operation, and
+// returns its path (or "/dev/null" if that version of the entity does not
+// exist).
+func (e *Entity) diffVersion(version string, actualState EntityDefinition, tempDir string) (string, error) {
+	var state EntityDefinition
+	switch version {
+	case "current":
+		state = actualState
+	case "provisioned", "base", "desired":
+		imageDir := ProvisionedImageDir
+		if version != "provisioned" {
+			imageDir = BaseImageDir
+		}
+		image, err := imageDir.LoadImageFor(e.Definition)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				return "", err
+			}
+			if version == "provisioned" {
+				return "/dev/null", nil
+			}
+			//base image is written on first `apply` (see Apply())
+			image = actualState
+		}
+		state = image
+
+		if version == "desired" && !e.IsOrphaned() {
+			state, _, err = e.desiredState(actualState, image)
+			if err != nil {
+				return "", err
+			}
+		}
+	default:
+		return "", fmt.Errorf("unknown version %q", version)
+	}
+
+	if !state.IsProvisioned() {
+		return "/dev/null", nil
+	}
+	path := filepath.Join(tempDir, "version-"+version+".toml")
+	return path, SerializeDefinitionIntoFile(state, path)
+}
+
+// PrintCommandMessage formats and prints a message for Holo (on file
+// descriptor 3 in API version 3).
 func PrintCommandMessage(msg string, arguments ...interface{}) {
 	if len(arguments) > 0 {
 		msg = fmt.Sprintf(msg, arguments...)
 	}
-	_, err := os.NewFile(3, "file descriptor 3").Write([]byte(msg))
+	_, err := pluginapi.Messages().Write([]byte(msg))
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "!! %s\n", err.Error())
+		pluginapi.Errorf(err.Error())
 	}
 }
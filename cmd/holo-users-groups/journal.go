@@ -0,0 +1,251 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package entrypoint
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/holocm/holo/internal/pluginapi"
+)
+
+// journalEntry is the directory in which the prior state of an entity is
+// recorded for `holo rollback`. It contains three image directories: "actual"
+// (for the state in the user/group database, if the entity existed), "base"
+// and "provisioned" (for copies of the respective images, if they existed).
+type journalEntry string
+
+// journalEntryFor returns the journal entry for the given entity in the
+// journal directory given by Holo, or an empty string if there is none.
+func journalEntryFor(def EntityDefinition) journalEntry {
+	return journalEntry(pluginapi.JournalEntryPathForPlugin(def.EntityID()))
+}
+
+func (j journalEntry) imageDir(name string) ImageDir {
+	return ImageDir(filepath.Join(string(j), name))
+}
+
+// loadImage is like ImageDir.LoadImageFor, but returns a nil definition
+// instead of an error if the image does not exist.
+func (j journalEntry) loadImage(name string, def EntityDefinition) (EntityDefinition, error) {
+	return loadImageIfExists(j.imageDir(name), def)
+}
+
+func loadImageIfExists(dir ImageDir, def EntityDefinition) (EntityDefinition, error) {
+	image, err := dir.LoadImageFor(def)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return image, err
+}
+
+// journaledImageDir is an image directory whose images are copied into
+// journal entries.
+type journaledImageDir struct {
+	Name string
+	Dir  ImageDir
+}
+
+func journaledImageDirs() []journaledImageDir {
+	return []journaledImageDir{
+		{"base", BaseImageDir},
+		{"provisioned", ProvisionedImageDir},
+	}
+}
+
+// Save records the current state of the given entity in this journal entry.
+func (j journalEntry) Save(def EntityDefinition) error {
+	//the entry directory is created even if it stays empty (this means that
+	//the entity did not exist at all)
+	err := os.MkdirAll(string(j), 0700)
+	if err != nil {
+		return err
+	}
+
+	actualState, err := def.GetProvisionedState()
+	if err != nil {
+		return fmt.Errorf("cannot read %s database: %s", def.TypeName(), err.Error())
+	}
+	if actualState.IsProvisioned() {
+		err = j.saveImage("actual", actualState)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, d := range journaledImageDirs() {
+		image, err := loadImageIfExists(d.Dir, def)
+		if err != nil {
+			return err
+		}
+		if image != nil {
+			err = j.saveImage(d.Name, image)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (j journalEntry) saveImage(name string, def EntityDefinition) error {
+	dir := j.imageDir(name)
+	err := os.MkdirAll(string(dir), 0700)
+	if err != nil {
+		return err
+	}
+	return dir.SaveImage(def)
+}
+
+// IsUnchanged returns whether the current state of the given entity is still
+// the same as the state recorded in this journal entry.
+func (j journalEntry) IsUnchanged(def EntityDefinition) (bool, error) {
+	actualState, err := def.GetProvisionedState()
+	if err != nil {
+		return false, fmt.Errorf("cannot read %s database: %s", def.TypeName(), err.Error())
+	}
+	if !actualState.IsProvisioned() {
+		actualState = nil
+	}
+	priorState, err := j.loadImage("actual", def)
+	if err != nil {
+		return false, err
+	}
+	equal, err := definitionsEqual(actualState, priorState)
+	if !equal || err != nil {
+		return false, err
+	}
+
+	for _, d := range journaledImageDirs() {
+		current, err := loadImageIfExists(d.Dir, def)
+		if err != nil {
+			return false, err
+		}
+		prior, err := j.loadImage(d.Name, def)
+		if err != nil {
+			return false, err
+		}
+		equal, err := definitionsEqual(current, prior)
+		if !equal || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// Discard removes this journal entry.
+func (j journalEntry) Discard() error {
+	return os.RemoveAll(string(j))
+}
+
+// Restore restores the state of the given entity that was recorded in this
+// journal entry. The return value is false if nothing needed to be changed.
+func (j journalEntry) Restore(def EntityDefinition) (changed bool, err error) {
+	if j == "" {
+		return false, errors.New("no journal directory given")
+	}
+	_, err = os.Stat(string(j))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, fmt.Errorf("no journal entry for %s", def.EntityID())
+		}
+		return false, err
+	}
+
+	//restore the entity in the user/group database
+	actualState, err := def.GetProvisionedState()
+	if err != nil {
+		return false, fmt.Errorf("cannot read %s database: %s", def.TypeName(), err.Error())
+	}
+	priorState, err := j.loadImage("actual", def)
+	if err != nil {
+		return false, err
+	}
+	if priorState == nil {
+		//the entity did not exist before
+		if actualState.IsProvisioned() {
+			err = def.Cleanup()
+			if err != nil {
+				return false, err
+			}
+			changed = true
+		}
+	} else {
+		equal, err := definitionsEqual(priorState, actualState)
+		if err != nil {
+			return false, err
+		}
+		if !equal {
+			err = priorState.Apply(actualState)
+			if err != nil {
+				return false, err
+			}
+			StoreAppliedState(priorState, actualState)
+			changed = true
+		}
+	}
+
+	//restore the images
+	for _, d := range journaledImageDirs() {
+		current, err := loadImageIfExists(d.Dir, def)
+		if err != nil {
+			return false, err
+		}
+		prior, err := j.loadImage(d.Name, def)
+		if err != nil {
+			return false, err
+		}
+		equal, err := definitionsEqual(current, prior)
+		if err != nil {
+			return false, err
+		}
+		if equal {
+			continue
+		}
+		if prior == nil {
+			err = DeleteImageFor(def, d.Dir)
+		} else {
+			err = d.Dir.SaveImage(prior)
+		}
+		if err != nil {
+			return false, err
+		}
+		changed = true
+	}
+
+	return changed, nil
+}
+
+// definitionsEqual compares two definitions by their serialization. Either
+// argument may be nil to signify that the definition does not exist.
+func definitionsEqual(def1, def2 EntityDefinition) (bool, error) {
+	if def1 == nil || def2 == nil {
+		return def1 == nil && def2 == nil, nil
+	}
+	str1, err := SerializeDefinition(def1)
+	if err != nil {
+		return false, err
+	}
+	str2, err := SerializeDefinition(def2)
+	return string(str1) == string(str2), err
+}
@@ -22,17 +22,20 @@ package entrypoint
 
 import (
 	"encoding/gob"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+
+	"github.com/holocm/holo/internal/pluginapi"
 )
 
 // Main is the main entry point, but returns the exit code rather than
 // calling os.Exit().  This distinction is useful for monobinary and
 // testing purposes.
 func Main() (exitCode int) {
-	if version := os.Getenv("HOLO_API_VERSION"); version != "3" {
-		fmt.Fprintf(os.Stderr, "!! holo-users-groups plugin called with unknown HOLO_API_VERSION %s\n", version)
+	if version := os.Getenv("HOLO_API_VERSION"); version != "3" && version != "4" {
+		pluginapi.Errorf("holo-users-groups plugin called with unknown HOLO_API_VERSION %s", version)
 		return 1
 	}
 
@@ -40,7 +43,7 @@ func Main() (exitCode int) {
 		for _, dir := range []string{string(BaseImageDir), string(ProvisionedImageDir)} {
 			err := os.MkdirAll(dir, 0755)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "!! %s\n", err.Error())
+				pluginapi.Errorf(err.Error())
 				os.Exit(1)
 			}
 		}
@@ -50,20 +53,56 @@ func Main() (exitCode int) {
 	gob.Register(&UserDefinition{})
 	gob.Register(Entity{})
 
-	var err error
 	switch os.Args[1] {
 	case "info":
-		os.Stdout.Write([]byte("MIN_API_VERSION=3\nMAX_API_VERSION=3\n"))
+		os.Stdout.Write([]byte("MIN_API_VERSION=3\nMAX_API_VERSION=4\nOPTIONAL_OPERATIONS=plan rollback check\nVERSION=" + pluginapi.Version + "\nDESCRIPTION=Provisions user accounts and groups\nENTITY_PREFIXES=user: group:\n"))
+		return 0
+	case "serve":
+		//in a session, the scan result is kept in memory instead of in the cache file
+		var entities []*Entity
+		return pluginapi.Serve(func(args []string) int {
+			var err error
+			switch args[0] {
+			case "scan":
+				entities, err = executeScanCommand(false)
+			case "rollback":
+				err = executeRollbackCommand(args[1])
+			default:
+				if entities == nil {
+					entities, err = loadEntitiesFromCache()
+				}
+				if err == nil {
+					err = executeNonScanCommand(entities, args)
+				}
+			}
+			return reportError(err)
+		})
+	}
+
+	var err error
+	switch os.Args[1] {
 	case "scan":
-		err = executeScanCommand()
+		_, err = executeScanCommand(true)
+	case "rollback":
+		err = executeRollbackCommand(os.Args[2])
 	default:
-		err = executeNonScanCommand()
+		var entities []*Entity
+		entities, err = loadEntitiesFromCache()
+		if err == nil {
+			err = executeNonScanCommand(entities, os.Args[1:])
+		}
+	}
+	return reportError(err)
+}
+
+func reportError(err error) (exitCode int) {
+	if err == errScanFailed {
+		return 1
 	}
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "!! %s\n", err.Error())
+		pluginapi.Errorf(err.Error())
 		return 1
 	}
-
 	return 0
 }
 
@@ -71,52 +110,58 @@ func pathToCacheFile() string {
 	return filepath.Join(os.Getenv("HOLO_CACHE_DIR"), "entities.toml")
 }
 
-func executeScanCommand() error {
+// errScanFailed is returned by executeScanCommand() when the errors have
+// already been reported.
+var errScanFailed = errors.New("scan failed")
+
+func executeScanCommand(writeCache bool) ([]*Entity, error) {
 	//scan for entities
-	entities, errors := Scan()
-	for _, err := range errors {
-		fmt.Fprintf(os.Stderr, "!! %s\n", err.Error())
+	entities, errs := Scan()
+	for _, err := range errs {
+		pluginapi.Errorf(err.Error())
 	}
 	if entities == nil {
-		//some fatal error occurred - it was already reported, so just exit
-		os.Exit(1)
+		//some fatal error occurred - it was already reported
+		return nil, errScanFailed
 	}
 
 	//print reports
 	for _, entity := range entities {
 		entity.PrintReport()
 	}
+	if !writeCache {
+		return entities, nil
+	}
 
 	//store scan result in cache
 	file, err := os.Create(pathToCacheFile())
 	if err != nil {
-		return err
+		return nil, err
 	}
 	err = gob.NewEncoder(file).Encode(entities)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return file.Close()
+	return entities, file.Close()
 }
 
-func executeNonScanCommand() error {
-	//retrieve entities from cache
+func loadEntitiesFromCache() ([]*Entity, error) {
 	file, err := os.Open(pathToCacheFile())
 	if err != nil {
-		return err
+		return nil, err
 	}
 	var entities []*Entity
 	err = gob.NewDecoder(file).Decode(&entities)
 	if err != nil {
-		return err
-	}
-	err = file.Close()
-	if err != nil {
-		return err
+		return nil, err
 	}
+	return entities, file.Close()
+}
 
+func executeNonScanCommand(entities []*Entity, args []string) error {
 	//all other actions require an entity selection
-	entityID := os.Args[2]
+	entityID := args[1]
+
 	var selectedEntity *Entity
 	for _, entity := range entities {
 		if entity.Definition.EntityID() == entityID {
@@ -128,14 +173,40 @@ func executeNonScanCommand() error {
 		return fmt.Errorf("unknown entity ID \"%s\"", entityID)
 	}
 
-	switch os.Args[1] {
+	DryRun = args[0] == "plan" || args[0] == "force-plan"
+	switch args[0] {
 	case "apply":
-		return selectedEntity.Apply(false)
+		return selectedEntity.ApplyWithJournal(false)
 	case "force-apply":
+		return selectedEntity.ApplyWithJournal(true)
+	case "plan":
+		return selectedEntity.Apply(false)
+	case "force-plan":
 		return selectedEntity.Apply(true)
 	case "diff":
-		return selectedEntity.PrepareDiff()
+		return selectedEntity.PrepareDiff(args[2:])
+	case "check":
+		status, err := selectedEntity.Check()
+		if err != nil {
+			return err
+		}
+		PrintCommandMessage(status + "\n")
+		return nil
 	default:
-		return fmt.Errorf("unknown command '%s'", os.Args[1])
+		return fmt.Errorf("unknown command '%s'", args[0])
+	}
+}
+
+// executeRollbackCommand does not use the scan result since the entity might
+// not exist anymore.
+func executeRollbackCommand(entityID string) error {
+	def := definitionForEntityID(entityID)
+	if def == nil {
+		return fmt.Errorf("unknown entity ID \"%s\"", entityID)
+	}
+	changed, err := journalEntryFor(def).Restore(def)
+	if err == nil && !changed {
+		PrintCommandMessage("not changed\n")
 	}
+	return err
 }
@@ -27,22 +27,25 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+
+	"github.com/holocm/holo/internal/pluginapi"
 )
 
 var (
+	rootDirectory string
 	etcPasswdPath string
 	etcGroupPath  string
 	appliedStates map[string]EntityDefinition //= nil unless during tests
 )
 
 func init() {
-	rootDir := os.Getenv("HOLO_ROOT_DIR")
-	if rootDir == "" {
-		rootDir = "/"
+	rootDirectory = os.Getenv("HOLO_ROOT_DIR")
+	if rootDirectory == "" {
+		rootDirectory = "/"
 	}
-	etcPasswdPath = filepath.Join(rootDir, "etc/passwd")
-	etcGroupPath = filepath.Join(rootDir, "etc/group")
-	if rootDir != "/" {
+	etcPasswdPath = filepath.Join(rootDirectory, "etc/passwd")
+	etcGroupPath = filepath.Join(rootDirectory, "etc/group")
+	if pluginapi.IsTestMode() {
 		appliedStates = make(map[string]EntityDefinition)
 	}
 }
@@ -28,6 +28,7 @@ import (
 	"strings"
 
 	"github.com/BurntSushi/toml"
+	"github.com/holocm/holo/internal/pluginapi"
 )
 
 // Scan returns a slice of all the defined entities.
@@ -76,12 +77,9 @@ func Scan() ([]*Entity, []error) {
 		return nil, []error{err}
 	}
 	for _, id := range ids {
-		var def EntityDefinition
-		switch {
-		case strings.HasPrefix(id, "group:"):
-			def = &GroupDefinition{Name: strings.TrimPrefix(id, "group:")}
-		case strings.HasPrefix(id, "user:"):
-			def = &UserDefinition{Name: strings.TrimPrefix(id, "user:")}
+		def := definitionForEntityID(id)
+		if def == nil {
+			continue
 		}
 		if _, ok := entities[def.EntityID()]; !ok {
 			entities[def.EntityID()] = &Entity{Definition: def}
@@ -138,17 +136,33 @@ func readDefinitionFile(definitionPath string, entities *map[string]*Entity) err
 	if err != nil {
 		return &FileInvalidError{definitionPath, []error{err}}
 	}
+	//tags are not part of the definition (they are not provisioned and
+	//must not end up in the base images), so collect them separately
+	var tagLists struct {
+		Group []struct {
+			Tags []string `toml:"tags"`
+		}
+		User []struct {
+			Tags []string `toml:"tags"`
+		}
+	}
+	_, err = toml.Decode(string(blob), &tagLists)
+	if err != nil {
+		return &FileInvalidError{definitionPath, []error{err}}
+	}
 
 	//when checking the entity definitions, report all errors at once
 	var errors []error
 
 	//collect the definitions in this file
 	defs := make([]EntityDefinition, 0, len(contents.Group)+len(contents.User))
+	tags := make([][]string, 0, len(contents.Group)+len(contents.User))
 	for idx, group := range contents.Group {
 		if group.Name == "" {
 			errors = append(errors, fmt.Errorf("groups[%d] is missing required 'name' attribute", idx))
 		} else {
 			defs = append(defs, group)
+			tags = append(tags, tagLists.Group[idx].Tags)
 		}
 	}
 	for idx, user := range contents.User {
@@ -157,11 +171,23 @@ func readDefinitionFile(definitionPath string, entities *map[string]*Entity) err
 			continue
 		} else {
 			defs = append(defs, user)
+			tags = append(tags, tagLists.User[idx].Tags)
 		}
 	}
+	for idx, tagList := range tags {
+		var validTags []string
+		for _, tag := range tagList {
+			if isValidTag(tag) {
+				validTags = append(validTags, tag)
+			} else {
+				errors = append(errors, fmt.Errorf("%s has invalid tag %q", defs[idx].EntityID(), tag))
+			}
+		}
+		tags[idx] = validTags
+	}
 
 	//merge definitions into existing entities where appropriate
-	for _, def := range defs {
+	for idx, def := range defs {
 		id := def.EntityID()
 		entity, exists := (*entities)[id]
 		if exists {
@@ -179,6 +205,7 @@ func readDefinitionFile(definitionPath string, entities *map[string]*Entity) err
 			(*entities)[id] = entity
 		}
 		entity.DefinitionFiles = append(entity.DefinitionFiles, definitionPath)
+		entity.AddTags(tags[idx]...)
 	}
 
 	if len(errors) > 0 {
@@ -209,9 +236,9 @@ func migrateOldRegistry() error {
 	if err != nil {
 		return err
 	}
-	fmt.Fprintf(os.Stderr, ">> Migrating %s...\n", statePath)
-	fmt.Fprintf(os.Stderr, "!! This might require manual intervention! Please find instructions at:\n")
-	fmt.Fprintf(os.Stderr, "!!   <https://github.com/holocm/holo-users-groups/wiki/Migrating-to-v2.0>\n")
+	pluginapi.Warnf("Migrating %s...", statePath)
+	pluginapi.Errorf("This might require manual intervention! Please find instructions at:")
+	pluginapi.Errorf("  <https://github.com/holocm/holo-users-groups/wiki/Migrating-to-v2.0>")
 
 	//migrate each provisioned entity
 	for _, groupName := range state.ProvisionedGroups {
@@ -228,7 +255,7 @@ func migrateOldRegistry() error {
 	}
 
 	//all went well - drop state.toml
-	fmt.Fprintf(os.Stderr, ">> All entities migrated. Removing %s...\n", statePath)
+	pluginapi.Warnf("All entities migrated. Removing %s...", statePath)
 	return os.Remove(statePath)
 }
 
@@ -239,11 +266,31 @@ func migrateEntity(emptyBaseImage EntityDefinition) error {
 		return err
 	}
 	if baseImage != nil {
-		fmt.Fprintf(os.Stderr, ">> Skipping %s (found existing base image)\n", emptyBaseImage.EntityID())
+		pluginapi.Warnf("Skipping %s (found existing base image)", emptyBaseImage.EntityID())
 		return nil
 	}
 
 	//write the empty base image
-	fmt.Fprintf(os.Stderr, ">> Writing empty base image for %s\n", emptyBaseImage.EntityID())
+	pluginapi.Warnf("Writing empty base image for %s", emptyBaseImage.EntityID())
 	return BaseImageDir.SaveImage(emptyBaseImage)
 }
+
+// isValidTag checks whether the given string can be used as a tag. Tags
+// appear in selectors like "tag:NAME", so they must not contain whitespace
+// or characters that would turn the selector into a glob.
+func isValidTag(tag string) bool {
+	return tag != "" && !strings.ContainsAny(tag, " \t\n*?[]")
+}
+
+// definitionForEntityID returns an empty definition for the entity with the
+// given ID, or nil if the ID is not valid.
+func definitionForEntityID(id string) EntityDefinition {
+	switch {
+	case strings.HasPrefix(id, "group:"):
+		return &GroupDefinition{Name: strings.TrimPrefix(id, "group:")}
+	case strings.HasPrefix(id, "user:"):
+		return &UserDefinition{Name: strings.TrimPrefix(id, "user:")}
+	default:
+		return nil
+	}
+}
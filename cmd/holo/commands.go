@@ -0,0 +1,450 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+package entrypoint
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	impl "github.com/holocm/holo/cmd/holo/internal"
+)
+
+// commandDefinition describes a subcommand of holo. The same definitions are
+// used for parsing the command line, for `holo help` and for generating shell
+// completion scripts with `holo completion`.
+type commandDefinition struct {
+	name        string
+	aliases     []string
+	description string //shown by shell completions
+	usage       string //shown by `holo help` after the command name
+	arguments   argumentKind
+	options     []*optionDefinition
+	//exactly one of these is set: run() for commands that work on entities,
+	//standalone() for commands that do not need any configuration
+	run        func([]*impl.Entity, *commandLine) int
+	standalone func(args []string) int
+}
+
+// argumentKind describes which positional arguments a command accepts.
+type argumentKind int
+
+const (
+	noArguments argumentKind = iota
+	//any number of selectors
+	selectorArguments
+	//an optional run ID, followed by any number of selectors
+	runIDArguments
+	//the name of a shell
+	shellArguments
+)
+
+// optionDefinition describes an option that is accepted by a command.
+type optionDefinition struct {
+	names       []string //e.g. {"-f", "--force"}
+	description string   //shown by shell completions
+	//for options without an argument: which entry to set in the options map
+	flag int
+	//for options with an argument: a placeholder for the argument (e.g.
+	//"TIMEOUT") and how to parse it
+	argument string
+	parse    func(line *commandLine, value string) error
+	//if set, the argument can be omitted (and parse() receives "")
+	optionalArgument bool
+	//if set, the option may be given multiple times
+	repeatable bool
+	//for shell completions: the possible values of the argument (if there is
+	//a fixed set), or whether the argument is a selector or a directory
+	choices           []string
+	argumentSelector  bool
+	argumentDirectory bool
+	//for shell completions: other options that cannot be combined with this one
+	conflicts []string
+}
+
+// longName returns the name of the option that is used in error messages.
+func (o *optionDefinition) longName() string {
+	return o.names[len(o.names)-1]
+}
+
+// commandLine collects the result of parsing the command line.
+type commandLine struct {
+	options     map[int]bool
+	selectors   []*impl.Selector
+	plugins     []*impl.Plugin //from holorc
+	lockTimeout time.Duration
+	timeouts    impl.Timeouts
+	diffFrom    string //from `holo diff --between=FROM,TO`
+	diffTo      string
+	watchHook   string //from `holo watch --hook=COMMAND`
+}
+
+// commands is the list of all subcommands, in the order in which they are
+// shown by `holo help`. It is filled in init() since some commands refer to it.
+var commands []*commandDefinition
+
+func init() {
+	commands = []*commandDefinition{
+		{
+			name:        "apply",
+			description: "Apply available configuration to some or all entities",
+			usage:       "[-f|--force] [-n|--dry-run|-i|--interactive] [--json] [--wait[=TIMEOUT]] [selector ...]",
+			arguments:   selectorArguments,
+			run:         commandApply,
+			options: []*optionDefinition{
+				{names: []string{"-f", "--force"}, flag: optionApplyForce,
+					description: "overwrite manual changes on entities"},
+				{names: []string{"-n", "--dry-run"}, flag: optionApplyDryRun,
+					description: "only show what would be changed",
+					conflicts:   []string{"-i", "--interactive"}},
+				{names: []string{"-i", "--interactive"}, flag: optionApplyInteractive,
+					description: "ask before applying each entity",
+					conflicts:   []string{"-n", "--dry-run", "--json"}},
+				{names: []string{"--json"}, flag: optionJSON,
+					description: "print outcome for each entity as JSON",
+					conflicts:   []string{"-i", "--interactive"}},
+			},
+		},
+		{
+			name:        "check",
+			description: "Report entities that are not in sync with their configuration",
+			usage:       "[--wait[=TIMEOUT]] [selector ...]",
+			arguments:   selectorArguments,
+			run:         commandCheck,
+		},
+		{
+			name:        "completion",
+			description: "Print a shell completion script",
+			usage:       "bash|zsh|fish",
+			arguments:   shellArguments,
+			standalone:  commandCompletion,
+		},
+		{
+			name:        "diff",
+			description: "Diff some or all entities against the last provisioned version",
+			usage:       "[--stat|--name-only] [-U NUM|--unified=NUM] [--between=FROM,TO] [--wait[=TIMEOUT]] [selector ...]",
+			arguments:   selectorArguments,
+			run:         commandDiff,
+			options: []*optionDefinition{
+				{names: []string{"--stat"}, flag: optionDiffStat,
+					description: "only show a summary of the changes",
+					conflicts:   []string{"--name-only"}},
+				{names: []string{"--name-only"}, flag: optionDiffNameOnly,
+					description: "only show the IDs of changed entities",
+					conflicts:   []string{"--stat"}},
+				{names: []string{"-U", "--unified"}, argument: "NUM", parse: parseDiffContextLines,
+					description: "number of context lines"},
+				{names: []string{"--between"}, argument: "FROM,TO", parse: parseDiffVersions,
+					description: "versions to compare",
+					choices: []string{"provisioned,current", "desired,current", "base,desired",
+						"base,current", "provisioned,desired"}},
+			},
+		},
+		{
+			name:        "explain",
+			description: "Show how the desired state of some or all entities is computed",
+			usage:       "[--wait[=TIMEOUT]] [selector ...]",
+			arguments:   selectorArguments,
+			run:         commandExplain,
+		},
+		{
+			name:        "facts",
+			description: "Show the facts and variables provided to generators and plugins",
+			usage:       "[--json]",
+			run:         commandFacts,
+			options: []*optionDefinition{
+				{names: []string{"--json"}, flag: optionJSON,
+					description: "print facts as JSON"},
+			},
+		},
+		{
+			name:        "log",
+			description: "Show the history of previous applies",
+			usage:       "[-a|--all] [--json] [--wait[=TIMEOUT]] [selector ...]",
+			arguments:   selectorArguments,
+			run:         commandLog,
+			options: []*optionDefinition{
+				{names: []string{"-a", "--all"}, flag: optionLogAll,
+					description: "also list unchanged entities"},
+				{names: []string{"--json"}, flag: optionJSON,
+					description: "print history as JSON"},
+			},
+		},
+		{
+			name:        "plugins",
+			description: "List the configured plugins and their capabilities",
+			usage:       "[--json]",
+			run:         commandPlugins,
+			options: []*optionDefinition{
+				{names: []string{"--json"}, flag: optionJSON,
+					description: "print plugins as JSON"},
+			},
+		},
+		{
+			name:        "rollback",
+			description: "Revert the changes made by a previous apply",
+			usage:       "[--wait[=TIMEOUT]] [run-id] [selector ...]",
+			arguments:   runIDArguments,
+			run:         commandRollback,
+		},
+		{
+			name:        "scan",
+			description: "Scan for provisionable entities",
+			usage:       "[-s|--short|-p|--porcelain|--json] [--wait[=TIMEOUT]] [selector ...]",
+			arguments:   selectorArguments,
+			run:         commandScan,
+			options: []*optionDefinition{
+				{names: []string{"-s", "--short"}, flag: optionScanShort,
+					description: "print only entity names",
+					conflicts:   []string{"-p", "--porcelain", "--json"}},
+				{names: []string{"-p", "--porcelain"}, flag: optionScanPorcelain,
+					description: "print raw scan reports",
+					conflicts:   []string{"-s", "--short", "--json"}},
+				{names: []string{"--json"}, flag: optionJSON,
+					description: "print scan reports as JSON",
+					conflicts:   []string{"-s", "--short", "-p", "--porcelain"}},
+			},
+		},
+		{
+			name:        "selectors",
+			description: "List all valid selectors",
+			usage:       "[--json] [--wait[=TIMEOUT]] [selector ...]",
+			arguments:   selectorArguments,
+			run:         commandSelectors,
+			options: []*optionDefinition{
+				{names: []string{"--json"}, flag: optionJSON,
+					description: "print selectors and matched entities as JSON"},
+			},
+		},
+		{
+			name:        "watch",
+			description: "Watch some or all entities for changes",
+			usage:       "[--policy=report|--policy=apply] [--hook=COMMAND] [--wait[=TIMEOUT]] [selector ...]",
+			arguments:   selectorArguments,
+			run:         commandWatch,
+			options: []*optionDefinition{
+				{names: []string{"--policy"}, argument: "POLICY", parse: parseWatchPolicy,
+					description: "what to do with changed entities",
+					choices:     []string{"report", "apply"}},
+				{names: []string{"--hook"}, argument: "COMMAND", parse: parseWatchHook,
+					description: "shell command to run for changed entities"},
+			},
+		},
+		{
+			name:        "version",
+			aliases:     []string{"--version"},
+			description: "Print a short version string",
+			standalone: func(args []string) int {
+				fmt.Println(version)
+				return 0
+			},
+		},
+		{
+			name:        "help",
+			aliases:     []string{"--help"},
+			description: "Print short usage information",
+			standalone: func(args []string) int {
+				commandHelp(os.Stdout)
+				return 0
+			},
+		},
+	}
+}
+
+// commonOptions are accepted by all commands that work on entities.
+var commonOptions = []*optionDefinition{
+	{names: []string{"--wait"}, argument: "TIMEOUT", parse: parseWait, optionalArgument: true,
+		description: "wait for other running instances of Holo"},
+	{names: []string{"--timeout"}, argument: "[OPERATION=]DURATION", parse: parseTimeout, repeatable: true,
+		description: "limit how long plugins may take"},
+	colorOption,
+	rootOption,
+}
+
+// colorOption is evaluated before all other options, see Main().
+var colorOption = &optionDefinition{
+	names: []string{"--color"}, argument: "WHEN", parse: parseColor, optionalArgument: true,
+	description: "choose whether output is colored",
+	choices:     []string{"auto", "always", "never"},
+}
+
+// rootOption is evaluated before all other options (and before holorc is
+// read), see Main().
+var rootOption = &optionDefinition{
+	names: []string{"--root"}, argument: "DIR", parse: parseRoot,
+	description:       "provision the system mounted at this directory",
+	argumentDirectory: true,
+}
+
+// selectorOptions are accepted by all commands that accept selectors.
+var selectorOptions = []*optionDefinition{
+	{names: []string{"--exclude"}, argument: "SELECTOR", parse: parseExclude, repeatable: true,
+		description:      "deselect entities matching this selector",
+		argumentSelector: true},
+}
+
+// findCommand returns the command with the given name or alias, or nil.
+func findCommand(name string) *commandDefinition {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+		for _, alias := range cmd.aliases {
+			if alias == name {
+				return cmd
+			}
+		}
+	}
+	return nil
+}
+
+// allOptions returns the options that are specific to this command, followed
+// by all generic options that it accepts.
+func (c *commandDefinition) allOptions() []*optionDefinition {
+	if c.run == nil {
+		return c.options
+	}
+	result := append([]*optionDefinition(nil), c.options...)
+	if c.arguments == selectorArguments || c.arguments == runIDArguments {
+		result = append(result, selectorOptions...)
+	}
+	return append(result, commonOptions...)
+}
+
+// errMissingArgument is returned by matchOption when an option that requires
+// an argument is the last argument on the command line.
+var errMissingArgument = errors.New("missing value")
+
+// matchOption checks whether args[idx] is one of the given options. If the
+// option takes an argument, its value is returned, and consumed is the number
+// of additional elements of args that were consumed for it.
+func matchOption(options []*optionDefinition, args []string, idx int) (opt *optionDefinition, value string, consumed int, err error) {
+	arg := args[idx]
+	for _, opt := range options {
+		for _, name := range opt.names {
+			switch {
+			case arg == name:
+				if opt.argument == "" || opt.optionalArgument {
+					return opt, "", 0, nil
+				}
+				if idx+1 >= len(args) {
+					return opt, "", 0, errMissingArgument
+				}
+				return opt, args[idx+1], 1, nil
+			case opt.argument == "":
+				//flags only match exactly
+			case strings.HasPrefix(name, "--") && strings.HasPrefix(arg, name+"="):
+				return opt, strings.TrimPrefix(arg, name+"="), 0, nil
+			case !strings.HasPrefix(name, "--") && strings.HasPrefix(arg, name):
+				//short options can have the value attached, e.g. "-U5"
+				return opt, strings.TrimPrefix(arg, name), 0, nil
+			}
+		}
+	}
+	return nil, "", 0, nil
+}
+
+func parseWait(line *commandLine, value string) (err error) {
+	if value == "" {
+		line.lockTimeout = impl.WaitForever
+		return nil
+	}
+	line.lockTimeout, err = impl.ParseDuration(value)
+	return err
+}
+
+func parseTimeout(line *commandLine, value string) error {
+	return line.timeouts.Set(value)
+}
+
+func parseColor(line *commandLine, value string) error {
+	if value == "" {
+		value = "always"
+	}
+	mode, err := impl.ParseColorMode(value)
+	if err == nil {
+		impl.SetColorMode(mode)
+	}
+	return err
+}
+
+func parseRoot(line *commandLine, value string) error {
+	return impl.SetRootDirectory(value)
+}
+
+func parseExclude(line *commandLine, value string) error {
+	selector, err := impl.NewSelector(value, true)
+	if err == nil {
+		line.selectors = append(line.selectors, selector)
+	}
+	return err
+}
+
+func parseDiffContextLines(line *commandLine, value string) error {
+	contextLines, err := strconv.Atoi(value)
+	if err != nil || contextLines < 0 {
+		return fmt.Errorf("%q is not a non-negative number", value)
+	}
+	impl.SetDiffContextLines(contextLines)
+	return nil
+}
+
+func parseDiffVersions(line *commandLine, value string) (err error) {
+	line.diffFrom, line.diffTo, err = impl.ParseDiffVersions(value)
+	return err
+}
+
+func parseWatchPolicy(line *commandLine, value string) error {
+	switch value {
+	case "report":
+		line.options[optionWatchReport] = true
+	case "apply":
+		line.options[optionWatchApply] = true
+	default:
+		return fmt.Errorf(`%q is not one of "report" or "apply"`, value)
+	}
+	return nil
+}
+
+func parseWatchHook(line *commandLine, value string) error {
+	line.watchHook = value
+	return nil
+}
+
+func commandHelp(w io.Writer) {
+	program := os.Args[0]
+	prefix := "Usage:"
+	for _, cmd := range commands {
+		usage := cmd.name
+		if cmd.usage != "" {
+			usage += " " + cmd.usage
+		}
+		fmt.Fprintf(w, "%6s %s %s\n", prefix, program, usage)
+		prefix = "or:"
+	}
+	fmt.Fprintf(w, "\nAll commands accept --timeout=[OPERATION=]DURATION to limit how long plugins may take,\n")
+	fmt.Fprintf(w, "--color=auto|always|never to choose whether output is colored, and --root=DIR to\n")
+	fmt.Fprintf(w, "provision the system mounted at DIR instead of the running system.\n")
+	fmt.Fprintf(w, "See `man 8 holo` for details.\n")
+}
@@ -0,0 +1,373 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+package entrypoint
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	impl "github.com/holocm/holo/cmd/holo/internal"
+)
+
+// completionShells are the shells supported by `holo completion`.
+var completionShells = []string{"bash", "zsh", "fish"}
+
+func commandCompletion(args []string) int {
+	shell := ""
+	if len(args) == 1 {
+		shell = args[0]
+	}
+	switch shell {
+	case "bash":
+		writeBashCompletion(os.Stdout)
+	case "fish":
+		writeFishCompletion(os.Stdout)
+	case "zsh":
+		writeZshCompletion(os.Stdout)
+	default:
+		impl.Errorf(impl.Stderr, "usage: %s completion %s", os.Args[0], strings.Join(completionShells, "|"))
+		return impl.ExitUsage
+	}
+	return 0
+}
+
+// shellQuote quotes a string for use in a shell script (this works for all
+// supported shells).
+func shellQuote(text string) string {
+	return "'" + strings.ReplaceAll(text, "'", `'\''`) + "'"
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// bash
+
+func writeBashCompletion(w io.Writer) {
+	fmt.Fprint(w, `# bash completion for holo(8), generated by "holo completion bash"
+
+# The output of "holo selectors" is cached for one minute since it requires a
+# full scan.
+_holo_selectors() {
+    local cache="${XDG_CACHE_HOME:-$HOME/.cache}/holo/selectors"
+    if [ -z "$(find "$cache" -mmin -1 2>/dev/null)" ]; then
+        mkdir -p "${cache%/*}" && holo selectors >"$cache.$$" 2>/dev/null && mv "$cache.$$" "$cache"
+        rm -f "$cache.$$"
+    fi
+    cat "$cache" 2>/dev/null
+}
+
+_holo() {
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    local prev="${COMP_WORDS[COMP_CWORD-1]}"
+    local option=""
+
+    if [ "$COMP_CWORD" = 1 ]; then
+`)
+	var names []string
+	for _, cmd := range commands {
+		names = append(names, cmd.name)
+		names = append(names, cmd.aliases...)
+	}
+	fmt.Fprintf(w, "        COMPREPLY=( $(compgen -W %s -- \"$cur\") )\n", shellQuote(strings.Join(names, " ")))
+	fmt.Fprint(w, `        return 0
+    fi
+
+    # find the option whose argument is completed (the argument is either
+    # given after "=", which bash treats as a separate word, or as the next word)
+    if [ "$cur" = "=" ]; then
+        option="$prev"
+        cur=""
+    elif [ "$prev" = "=" ]; then
+        option="${COMP_WORDS[COMP_CWORD-2]}"
+    else
+        case "$prev" in
+`)
+	var optionNames []string
+	for _, opt := range uniqueOptions() {
+		if opt.argument != "" && !opt.optionalArgument {
+			optionNames = append(optionNames, opt.names...)
+		}
+	}
+	fmt.Fprintf(w, "            %s) option=\"$prev\" ;;\n", strings.Join(optionNames, "|"))
+	fmt.Fprint(w, `        esac
+    fi
+    if [ -n "$option" ]; then
+        case "$option" in
+`)
+	for _, opt := range uniqueOptions() {
+		if opt.argumentDirectory {
+			fmt.Fprintf(w, "            %s) COMPREPLY=( $(compgen -d -- \"$cur\") ) ;;\n", strings.Join(opt.names, "|"))
+			continue
+		}
+		words := bashWords(strings.Join(opt.choices, " "), opt.argumentSelector)
+		if words != `""` {
+			fmt.Fprintf(w, "            %s) COMPREPLY=( $(compgen -W %s -- \"$cur\") ) ;;\n", strings.Join(opt.names, "|"), words)
+		}
+	}
+	fmt.Fprint(w, `            *) COMPREPLY=() ;;
+        esac
+        return 0
+    fi
+
+    case "${COMP_WORDS[1]}" in
+`)
+	for _, cmd := range commands {
+		var words []string
+		for _, opt := range cmd.allOptions() {
+			for _, name := range opt.names {
+				switch {
+				case opt.argument == "" || !strings.HasPrefix(name, "--"):
+					words = append(words, name)
+				case opt.optionalArgument && len(opt.choices) == 0:
+					words = append(words, name)
+				default:
+					words = append(words, name+"=")
+				}
+			}
+		}
+		switch cmd.arguments {
+		case runIDArguments:
+			words = append(words, "$(ls /var/lib/holo/journal 2>/dev/null)")
+		case shellArguments:
+			words = append(words, completionShells...)
+		}
+		wordList := bashWords(strings.Join(words, " "), cmd.arguments == selectorArguments || cmd.arguments == runIDArguments)
+		if wordList != `""` {
+			fmt.Fprintf(w, "        %s) COMPREPLY=( $(compgen -W %s -- \"$cur\") ) ;;\n", cmd.name, wordList)
+		}
+	}
+	fmt.Fprint(w, `    esac
+    return 0
+}
+complete -F _holo holo
+`)
+}
+
+// uniqueOptions returns all options of all commands, without duplicates.
+func uniqueOptions() []*optionDefinition {
+	var result []*optionDefinition
+	isSeen := make(map[string]bool)
+	for _, cmd := range commands {
+		for _, opt := range cmd.allOptions() {
+			if !isSeen[opt.longName()] {
+				isSeen[opt.longName()] = true
+				result = append(result, opt)
+			}
+		}
+	}
+	return result
+}
+
+// bashWords returns a double-quoted word list for `compgen -W`.
+func bashWords(words string, withSelectors bool) string {
+	if withSelectors {
+		words = strings.TrimSpace(words + " $(_holo_selectors)")
+	}
+	return `"` + words + `"`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// zsh
+
+func writeZshCompletion(w io.Writer) {
+	fmt.Fprint(w, `#compdef holo
+# zsh completion for holo(8), generated by "holo completion zsh"
+
+# The output of "holo selectors" is cached for one minute since it requires a
+# full scan.
+(( $+functions[_holo_selector] )) || _holo_selector()
+{
+    local cache="${XDG_CACHE_HOME:-$HOME/.cache}/holo/selectors"
+    local -a selectors
+    if [[ -z "$(find "$cache" -mmin -1 2>/dev/null)" ]]; then
+        mkdir -p "${cache%/*}" && holo selectors >"$cache.$$" 2>/dev/null && mv "$cache.$$" "$cache"
+        rm -f "$cache.$$"
+    fi
+    selectors=( ${(f)"$(cat "$cache" 2>/dev/null)"} )
+    _wanted selectors expl 'Holo selector' compadd -a selectors
+}
+
+(( $+functions[_holo_run_id] )) || _holo_run_id()
+{
+    _alternative "runs:Holo apply runs:($(ls /var/lib/holo/journal 2>/dev/null))" "selectors:Holo selectors:_holo_selector"
+}
+
+_holo()
+{
+    if (( CURRENT == 2 )); then
+        local -a _commands
+        _commands=(
+`)
+	for _, cmd := range commands {
+		for _, name := range append([]string{cmd.name}, cmd.aliases...) {
+			fmt.Fprintf(w, "            %s\n", shellQuote(name+":"+cmd.description))
+		}
+	}
+	fmt.Fprint(w, `        )
+        _describe -t commands 'holo command' _commands
+        return 0
+    fi
+
+    case "$words[2]" in
+`)
+	for _, cmd := range commands {
+		var specs []string
+		for _, opt := range cmd.allOptions() {
+			specs = append(specs, zshOptionSpec(opt))
+		}
+		switch cmd.arguments {
+		case selectorArguments:
+			specs = append(specs, `'*:selector:_holo_selector'`)
+		case runIDArguments:
+			specs = append(specs, `'1::run ID or selector:_holo_run_id'`, `'*:selector:_holo_selector'`)
+		case shellArguments:
+			specs = append(specs, shellQuote("1:shell:("+strings.Join(completionShells, " ")+")"))
+		}
+		if len(specs) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "        %s)\n            _arguments : \\\n                %s\n            ;;\n",
+			cmd.name, strings.Join(specs, " \\\n                "))
+	}
+	fmt.Fprint(w, `    esac
+    return 0
+}
+
+_holo "$@"
+`)
+}
+
+// zshOptionSpec returns the spec for the given option for zsh's _arguments.
+func zshOptionSpec(opt *optionDefinition) string {
+	var prefix string
+	if len(opt.conflicts) > 0 {
+		prefix = "(" + strings.Join(append(append([]string(nil), opt.names...), opt.conflicts...), " ") + ")"
+	}
+	if opt.repeatable {
+		prefix += "*"
+	}
+
+	names := make([]string, len(opt.names))
+	for idx, name := range opt.names {
+		switch {
+		case opt.argument == "":
+			names[idx] = name
+		case !strings.HasPrefix(name, "--"):
+			names[idx] = name + "+"
+		case opt.optionalArgument:
+			names[idx] = name + "=-"
+		default:
+			names[idx] = name + "="
+		}
+	}
+
+	suffix := "[" + opt.description + "]"
+	if opt.argument != "" {
+		if opt.optionalArgument {
+			suffix += ":"
+		}
+		suffix += ":" + strings.ToLower(opt.argument) + ":"
+		switch {
+		case len(opt.choices) > 0:
+			suffix += "(" + strings.Join(opt.choices, " ") + ")"
+		case opt.argumentSelector:
+			suffix += "_holo_selector"
+		case opt.argumentDirectory:
+			suffix += "_files -/"
+		}
+	}
+
+	if len(names) == 1 {
+		return shellQuote(prefix + names[0] + suffix)
+	}
+	result := "{" + strings.Join(names, ",") + "}" + shellQuote(suffix)
+	if prefix != "" {
+		result = shellQuote(prefix) + result
+	}
+	return result
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// fish
+
+func writeFishCompletion(w io.Writer) {
+	fmt.Fprint(w, `# fish completion for holo(8), generated by "holo completion fish"
+
+# The output of "holo selectors" is cached for one minute since it requires a
+# full scan.
+function __holo_selectors
+    set -l cache_dir $HOME/.cache/holo
+    set -q XDG_CACHE_HOME; and set cache_dir $XDG_CACHE_HOME/holo
+    if not find $cache_dir/selectors -mmin -1 2>/dev/null | string length -q
+        mkdir -p $cache_dir
+        and holo selectors >$cache_dir/selectors.$fish_pid 2>/dev/null
+        and mv $cache_dir/selectors.$fish_pid $cache_dir/selectors
+        rm -f $cache_dir/selectors.$fish_pid
+    end
+    cat $cache_dir/selectors 2>/dev/null
+end
+
+complete -c holo -f
+`)
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "complete -c holo -n __fish_use_subcommand -a %s -d %s\n", cmd.name, shellQuote(cmd.description))
+		for _, alias := range cmd.aliases {
+			fmt.Fprintf(w, "complete -c holo -n __fish_use_subcommand -l %s -d %s\n", strings.TrimPrefix(alias, "--"), shellQuote(cmd.description))
+		}
+	}
+
+	for _, cmd := range commands {
+		condition := shellQuote("__fish_seen_subcommand_from " + cmd.name)
+		for _, opt := range cmd.allOptions() {
+			line := "complete -c holo -n " + condition
+			for _, name := range opt.names {
+				if strings.HasPrefix(name, "--") {
+					line += " -l " + strings.TrimPrefix(name, "--")
+				} else {
+					line += " -s " + strings.TrimPrefix(name, "-")
+				}
+			}
+			switch {
+			case len(opt.choices) > 0:
+				line += " -x -a " + shellQuote(strings.Join(opt.choices, " "))
+			case opt.argumentSelector:
+				line += " -x -a '(__holo_selectors)'"
+			case opt.argumentDirectory:
+				line += " -x -a '(__fish_complete_directories)'"
+			case opt.argument != "" && !opt.optionalArgument:
+				line += " -x"
+			}
+			fmt.Fprintln(w, line+" -d "+shellQuote(opt.description))
+		}
+
+		var candidates string
+		switch cmd.arguments {
+		case selectorArguments:
+			candidates = "(__holo_selectors)"
+		case runIDArguments:
+			candidates = "(ls /var/lib/holo/journal 2>/dev/null) (__holo_selectors)"
+		case shellArguments:
+			candidates = strings.Join(completionShells, " ")
+		}
+		if candidates != "" {
+			fmt.Fprintf(w, "complete -c holo -n %s -a %s\n", condition, shellQuote(candidates))
+		}
+	}
+}
@@ -0,0 +1,127 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+package entrypoint
+
+import (
+	"bytes"
+	"io"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestCommandDefinitions(t *testing.T) {
+	for _, cmd := range commands {
+		if (cmd.run == nil) == (cmd.standalone == nil) {
+			t.Errorf("command %s: exactly one of run and standalone must be set", cmd.name)
+		}
+		isSeen := make(map[string]bool)
+		for _, opt := range cmd.allOptions() {
+			for _, name := range opt.names {
+				if isSeen[name] {
+					t.Errorf("command %s: option %s is defined multiple times", cmd.name, name)
+				}
+				isSeen[name] = true
+			}
+			if (opt.argument == "") != (opt.parse == nil) {
+				t.Errorf("command %s: option %s must have either both an argument and a parse function, or neither", cmd.name, opt.longName())
+			}
+			//these characters would need escaping in the completion scripts
+			if strings.ContainsAny(opt.description, `'[]:"$`) {
+				t.Errorf("command %s: description of option %s contains special characters", cmd.name, opt.longName())
+			}
+		}
+		if strings.ContainsAny(cmd.description, `'[]:"$`) {
+			t.Errorf("command %s: description contains special characters", cmd.name)
+		}
+	}
+}
+
+func TestMatchOption(t *testing.T) {
+	diff := findCommand("diff")
+	testcases := []struct {
+		args             []string
+		expectedOption   string
+		expectedValue    string
+		expectedConsumed int
+		expectError      bool
+	}{
+		{[]string{"--stat"}, "--stat", "", 0, false},
+		{[]string{"--stats"}, "", "", 0, false},
+		{[]string{"file:/etc/foo"}, "", "", 0, false},
+		{[]string{"-U5"}, "--unified", "5", 0, false},
+		{[]string{"-U", "5"}, "--unified", "5", 1, false},
+		{[]string{"--unified=5"}, "--unified", "5", 0, false},
+		{[]string{"--unified"}, "--unified", "", 0, true},
+		{[]string{"--wait"}, "--wait", "", 0, false},
+		{[]string{"--wait", "5"}, "--wait", "", 0, false},
+		{[]string{"--wait=5"}, "--wait", "5", 0, false},
+		{[]string{"--exclude", "file:/etc/foo"}, "--exclude", "file:/etc/foo", 1, false},
+	}
+	for _, tc := range testcases {
+		opt, value, consumed, err := matchOption(diff.allOptions(), tc.args, 0)
+		optionName := ""
+		if opt != nil {
+			optionName = opt.longName()
+		}
+		if optionName != tc.expectedOption || value != tc.expectedValue || consumed != tc.expectedConsumed || (err != nil) != tc.expectError {
+			t.Errorf("%q: expected option %q with value %q (consuming %d args, error = %t), got option %q with value %q (consuming %d args, error = %v)",
+				tc.args, tc.expectedOption, tc.expectedValue, tc.expectedConsumed, tc.expectError,
+				optionName, value, consumed, err)
+		}
+	}
+}
+
+func TestCompletionScripts(t *testing.T) {
+	generators := map[string]func(io.Writer){
+		"bash": writeBashCompletion,
+		"zsh":  writeZshCompletion,
+		"fish": writeFishCompletion,
+	}
+	for shell, generate := range generators {
+		var buf bytes.Buffer
+		generate(&buf)
+		script := buf.String()
+
+		//all commands and options must be mentioned
+		for _, cmd := range commands {
+			if !strings.Contains(script, cmd.name) {
+				t.Errorf("%s completion does not mention command %s", shell, cmd.name)
+			}
+			for _, opt := range cmd.allOptions() {
+				name := strings.TrimLeft(opt.longName(), "-")
+				if !strings.Contains(script, name) {
+					t.Errorf("%s completion does not mention option %s", shell, opt.longName())
+				}
+			}
+		}
+
+		//if the shell is installed, check the syntax of the script
+		if _, err := exec.LookPath(shell); err != nil {
+			continue
+		}
+		cmd := exec.Command(shell, "-n")
+		cmd.Stdin = strings.NewReader(script)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Errorf("%s completion has syntax errors: %s\n%s", shell, err.Error(), string(output))
+		}
+	}
+}
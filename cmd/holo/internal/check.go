@@ -0,0 +1,93 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CheckStatus is the result of Entity.Check().
+type CheckStatus int
+
+const (
+	//CheckInSync means that the entity is in its desired state.
+	CheckInSync CheckStatus = iota
+	//CheckPending means that the entity would be changed by `holo apply`
+	//(usually because its resources changed since the last apply).
+	CheckPending
+	//CheckDrifted means that the entity has been modified by the user since
+	//it was last provisioned, so `holo apply` would require --force.
+	CheckDrifted
+	//CheckOrphaned means that the entity's resources have been deleted, so
+	//`holo apply` would scrub it.
+	CheckOrphaned
+	//CheckFailed means that the plugin could not check the entity.
+	CheckFailed
+)
+
+var checkStatusStrings = []string{"in sync", "pending", "drifted", "orphaned", "failed"}
+
+// String returns the representation of the status that is used both in the
+// output of `holo check` and in the plugin interface.
+func (s CheckStatus) String() string {
+	return checkStatusStrings[s]
+}
+
+// parseCheckStatus parses the message that a plugin sends on file descriptor
+// 3 for the "check" operation.
+func parseCheckStatus(cmdText string) (CheckStatus, error) {
+	text := strings.TrimSpace(cmdText)
+	for idx, str := range checkStatusStrings[:CheckFailed] {
+		if text == str {
+			return CheckStatus(idx), nil
+		}
+	}
+	return CheckFailed, fmt.Errorf("unexpected response to \"check\" operation: %q", text)
+}
+
+// CheckExitCode returns the exit code of `holo check` for the given statuses.
+func CheckExitCode(statuses []CheckStatus) int {
+	exitCode := ExitSuccess
+	for _, status := range statuses {
+		switch status {
+		case CheckFailed:
+			return ExitErrors
+		case CheckPending, CheckDrifted, CheckOrphaned:
+			exitCode = ExitNotInSync
+		}
+	}
+	return exitCode
+}
+
+// Check asks the plugin whether this entity is in sync with its desired state,
+// without changing anything. Any output of the plugin goes into the given
+// writer, so that the caller can show it after the status.
+//
+// The caller must ensure that the plugin supports the "check" operation.
+func (e *Entity) Check(output io.Writer) (CheckStatus, error) {
+	cmdText, err := e.plugin.RunCommandWithFD3([]string{"check", e.id}, output, output)
+	if err != nil {
+		return CheckFailed, err
+	}
+	return parseCheckStatus(cmdText)
+}
@@ -0,0 +1,59 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import "testing"
+
+func TestParseCheckStatus(t *testing.T) {
+	for _, status := range []CheckStatus{CheckInSync, CheckPending, CheckDrifted, CheckOrphaned} {
+		actual, err := parseCheckStatus(status.String() + "\n")
+		if err != nil {
+			t.Errorf("unexpected error for %q: %s", status.String(), err.Error())
+		}
+		if actual != status {
+			t.Errorf("expected %q, got %q", status.String(), actual.String())
+		}
+	}
+	for _, text := range []string{"", "failed", "not changed\n"} {
+		actual, err := parseCheckStatus(text)
+		if err == nil {
+			t.Errorf("expected error for %q, got status %q", text, actual.String())
+		}
+	}
+}
+
+func TestCheckExitCode(t *testing.T) {
+	testcases := []struct {
+		statuses         []CheckStatus
+		expectedExitCode int
+	}{
+		{nil, ExitSuccess},
+		{[]CheckStatus{CheckInSync, CheckInSync}, ExitSuccess},
+		{[]CheckStatus{CheckInSync, CheckPending}, ExitNotInSync},
+		{[]CheckStatus{CheckOrphaned, CheckDrifted}, ExitNotInSync},
+		{[]CheckStatus{CheckDrifted, CheckFailed, CheckInSync}, ExitErrors},
+	}
+	for idx, tc := range testcases {
+		if actual := CheckExitCode(tc.statuses); actual != tc.expectedExitCode {
+			t.Errorf("testcase %d: expected exit code %d, got %d", idx, tc.expectedExitCode, actual)
+		}
+	}
+}
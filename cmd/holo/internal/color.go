@@ -0,0 +1,110 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// ColorMode is the value of the --color option.
+type ColorMode string
+
+// Acceptable values for ColorMode.
+const (
+	ColorAuto   ColorMode = "auto"
+	ColorAlways ColorMode = "always"
+	ColorNever  ColorMode = "never"
+)
+
+var (
+	//whether ANSI colors are used on os.Stdout and os.Stderr, respectively
+	stdoutColors = ColorAuto.decide(os.Stdout)
+	stderrColors = ColorAuto.decide(os.Stderr)
+)
+
+// ParseColorMode parses the value of the --color option.
+func ParseColorMode(value string) (ColorMode, error) {
+	switch mode := ColorMode(value); mode {
+	case ColorAuto, ColorAlways, ColorNever:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("%q is not one of \"auto\", \"always\" or \"never\"", value)
+	}
+}
+
+// SetColorMode decides whether ANSI colors are used in the output. In "auto"
+// mode, colors are used on stdout and stderr only if they are terminals, and
+// the NO_COLOR environment variable is not set.
+func SetColorMode(mode ColorMode) {
+	stdoutColors = mode.decide(os.Stdout)
+	stderrColors = mode.decide(os.Stderr)
+}
+
+func (mode ColorMode) decide(file *os.File) bool {
+	switch mode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	default:
+		return os.Getenv("NO_COLOR") == "" && isTerminal(file)
+	}
+}
+
+func isTerminal(file *os.File) bool {
+	var termios syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), syscall.TCGETS, uintptr(unsafe.Pointer(&termios)))
+	return errno == 0
+}
+
+// UseColors returns whether ANSI colors shall be used when writing to the
+// given writer. Writers that do not end up in os.Stdout follow the decision
+// for os.Stderr.
+func UseColors(writer io.Writer) bool {
+	for {
+		switch w := writer.(type) {
+		case *ParagraphWriter:
+			writer = w.Writer
+		case *PrologueWriter:
+			writer = w.Writer
+		case *LineColorizingWriter:
+			writer = w.Writer
+		default:
+			if writer == io.Writer(os.Stdout) {
+				return stdoutColors
+			}
+			return stderrColors
+		}
+	}
+}
+
+// colorEnviron returns the environment variable that tells plugins whether
+// they shall use ANSI colors on their stderr.
+func colorEnviron() string {
+	if stderrColors {
+		return "HOLO_COLOR=always"
+	}
+	return "HOLO_COLOR=never"
+}
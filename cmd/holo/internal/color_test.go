@@ -0,0 +1,85 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestParseColorMode(t *testing.T) {
+	for _, value := range []string{"auto", "always", "never"} {
+		mode, err := ParseColorMode(value)
+		if err != nil {
+			t.Errorf("unexpected error for %q: %s", value, err.Error())
+		}
+		if string(mode) != value {
+			t.Errorf("expected %q, got %q", value, mode)
+		}
+	}
+	if _, err := ParseColorMode("sometimes"); err == nil {
+		t.Error("expected error for \"sometimes\", got none")
+	}
+}
+
+func TestUseColors(t *testing.T) {
+	defer func(stdout, stderr bool) {
+		stdoutColors, stderrColors = stdout, stderr
+	}(stdoutColors, stderrColors)
+
+	SetColorMode(ColorAlways)
+	if !UseColors(Stdout) || !UseColors(Stderr) {
+		t.Error("expected colors on stdout and stderr with --color=always")
+	}
+	var buf bytes.Buffer
+	Errorf(&buf, "failed")
+	if buf.String() != "\x1b[1;31m!! failed\x1b[0m\n" {
+		t.Errorf("unexpected error message with --color=always: %q", buf.String())
+	}
+
+	SetColorMode(ColorNever)
+	if UseColors(Stdout) || UseColors(Stderr) {
+		t.Error("expected no colors on stdout and stderr with --color=never")
+	}
+	buf.Reset()
+	Warnf(&buf, "careful")
+	if buf.String() != ">> careful\n" {
+		t.Errorf("unexpected warning message with --color=never: %q", buf.String())
+	}
+
+	//in auto mode, NO_COLOR always disables colors
+	os.Setenv("NO_COLOR", "1")
+	defer os.Unsetenv("NO_COLOR")
+	SetColorMode(ColorAuto)
+	if UseColors(Stdout) || UseColors(Stderr) {
+		t.Error("expected no colors on stdout and stderr with NO_COLOR set")
+	}
+
+	//writers that end up in stdout follow the decision for stdout
+	stdoutColors, stderrColors = true, false
+	if !UseColors(&PrologueWriter{Writer: Stdout}) {
+		t.Error("expected PrologueWriter on Stdout to follow the decision for stdout")
+	}
+	if UseColors(&buf) {
+		t.Error("expected other writers to follow the decision for stderr")
+	}
+}
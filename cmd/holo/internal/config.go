@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 )
@@ -43,11 +44,41 @@ func RootDirectory() string {
 	return rootDirectory
 }
 
+// SetRootDirectory implements `holo --root`. Since $HOLO_ROOT_DIR is updated
+// accordingly, the new root directory is propagated to generators and plugins.
+func SetRootDirectory(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("%s is not a directory", path)
+	}
+	rootDirectory = path
+	return os.Setenv("HOLO_ROOT_DIR", path)
+}
+
 // Configuration contains the parsed contents of /etc/holorc.
 type Configuration struct {
 	Plugins []*Plugin
+	//one report for each plugin line (including the plugins that are skipped,
+	//see below), in the order of the plugin lines
+	pluginReports []PluginReport
+	//from "set NAME=value" lines
+	Variables map[string]string
+	//from "timeout [OPERATION=]DURATION" lines
+	Timeouts Timeouts
+	//from "alias NAME = SELECTOR..." (or "group NAME = SELECTOR...") lines
+	Aliases map[string][]string
 }
 
+var variableNameRx = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+var aliasNameRx = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
 // List config snippets in /etc/holorc.d.
 func listConfigSnippets() ([]string, error) {
 	dirPath := filepath.Join(RootDirectory(), "etc/holorc.d")
@@ -103,7 +134,26 @@ func ReadConfiguration() *Configuration {
 		return nil
 	}
 
-	var result Configuration
+	result := Configuration{
+		Variables: make(map[string]string),
+		Timeouts:  make(Timeouts),
+		Aliases:   make(map[string][]string),
+	}
+
+	//timeouts need to be known before the plugins are called (for the "info"
+	//operation), so collect them first
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "timeout ") {
+			err := result.Timeouts.Set(strings.TrimSpace(strings.TrimPrefix(line, "timeout")))
+			if err != nil {
+				Errorf(Stderr, "cannot parse configuration: invalid timeout: %s: %s", line, err.Error())
+				return nil
+			}
+		}
+	}
+	SetTimeouts(result.Timeouts)
+
 	for _, line := range lines {
 		//ignore comments and empty lines
 		line = strings.TrimSpace(line)
@@ -113,21 +163,24 @@ func ReadConfiguration() *Configuration {
 
 		//collect plugin IDs
 		if strings.HasPrefix(line, "plugin ") {
-			pluginID := strings.TrimSpace(strings.TrimPrefix(line, "plugin"))
-
-			var (
-				plugin *Plugin
-				err    error
-			)
-			if strings.Contains(pluginID, "=") {
-				fields := strings.SplitN(pluginID, "=", 2)
-				plugin, err = NewPluginWithExecutablePath(fields[0], fields[1])
-			} else {
-				plugin, err = NewPlugin(pluginID)
+			pluginID, settings, err := parsePluginLine(strings.TrimPrefix(line, "plugin"))
+			if err != nil {
+				Errorf(Stderr, "cannot parse configuration: %s", err.Error())
+				return nil
+			}
+
+			//plugins can be restricted to certain hosts or distributions
+			if condition := settings.FailedCondition(); condition != nil {
+				result.pluginReports = append(result.pluginReports,
+					skippedPluginReport(pluginID, settings, "condition "+condition.String()+" does not hold"))
+				continue
 			}
 
+			plugin, err := NewPluginWithSettings(pluginID, settings)
+
 			if err == nil {
 				result.Plugins = append(result.Plugins, plugin)
+				result.pluginReports = append(result.pluginReports, plugin.Report())
 			} else {
 				if err == ErrPluginExecutableMissing {
 					//this is not an error because we need a way to uninstall
@@ -136,11 +189,47 @@ func ReadConfiguration() *Configuration {
 					//holorc, but to be able to run, Holo needs to be able to
 					//ignore the missing uninstalled plugin at this point
 					Warnf(Stderr, "Skipping plugin: %s", pluginID)
+					result.pluginReports = append(result.pluginReports,
+						skippedPluginReport(pluginID, settings, "executable not found"))
 				} else {
 					Errorf(Stderr, err.Error())
 					return nil
 				}
 			}
+		} else if strings.HasPrefix(line, "timeout ") {
+			//already handled above
+			continue
+		} else if strings.HasPrefix(line, "set ") {
+			//collect variables (later assignments override earlier ones)
+			assignment := strings.TrimSpace(strings.TrimPrefix(line, "set"))
+			fields := strings.SplitN(assignment, "=", 2)
+			name := strings.TrimSpace(fields[0])
+			if len(fields) != 2 || !variableNameRx.MatchString(name) {
+				Errorf(Stderr, "cannot parse configuration: invalid variable assignment: %s", line)
+				return nil
+			}
+			result.Variables[name] = strings.TrimSpace(fields[1])
+		} else if strings.HasPrefix(line, "alias ") || strings.HasPrefix(line, "group ") {
+			//collect aliases (later definitions override earlier ones)
+			definition := strings.TrimSpace(strings.SplitN(line, " ", 2)[1])
+			fields := strings.SplitN(definition, "=", 2)
+			name := strings.TrimSpace(fields[0])
+			if len(fields) != 2 || !aliasNameRx.MatchString(name) {
+				Errorf(Stderr, "cannot parse configuration: invalid alias definition: %s", line)
+				return nil
+			}
+			members := strings.Fields(fields[1])
+			if len(members) == 0 {
+				Errorf(Stderr, "cannot parse configuration: alias %s is empty", name)
+				return nil
+			}
+			for _, member := range members {
+				if strings.HasPrefix(member, "!") {
+					Errorf(Stderr, "cannot parse configuration: alias %s cannot contain the exclusion %s", name, member)
+					return nil
+				}
+			}
+			result.Aliases[name] = members
 		} else {
 			//unknown line
 			Errorf(Stderr, "cannot parse configuration: unknown command: %s", line)
@@ -148,6 +237,26 @@ func ReadConfiguration() *Configuration {
 		}
 	}
 
+	//aliases must not shadow plugin IDs, and must not refer to themselves
+	aliasNames := make([]string, 0, len(result.Aliases))
+	for name := range result.Aliases {
+		aliasNames = append(aliasNames, name)
+	}
+	sort.Strings(aliasNames)
+	for _, name := range aliasNames {
+		for _, plugin := range result.Plugins {
+			if plugin.ID() == name {
+				Errorf(Stderr, "cannot parse configuration: alias %s has the same name as a plugin", name)
+				return nil
+			}
+		}
+		_, err := expandAlias(name, false, result.Aliases, nil)
+		if err != nil {
+			Errorf(Stderr, "cannot parse configuration: %s", err.Error())
+			return nil
+		}
+	}
+
 	//check existence of resource directories
 	hasError := false
 	for _, plugin := range result.Plugins {
@@ -183,3 +292,25 @@ func ReadConfiguration() *Configuration {
 
 	return &result
 }
+
+// PluginReports describes all plugins configured in holorc (in the order in
+// which they appear there), including those that were skipped because their
+// executable is missing or because their conditions do not hold.
+func (c *Configuration) PluginReports() []PluginReport {
+	return c.pluginReports
+}
+
+// skippedPluginReport describes a plugin that is configured in holorc, but not
+// used for the given reason.
+func skippedPluginReport(id string, settings PluginSettings, reason string) PluginReport {
+	return PluginReport{
+		ID:             id,
+		ExecutablePath: settings.ExecutablePath,
+		Skipped:        true,
+		SkipReason:     reason,
+		Environment:    []string{},
+		Conditions:     settings.ConditionStrings(),
+		EntityPrefixes: []string{},
+		Operations:     []string{},
+	}
+}
@@ -0,0 +1,75 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+package impl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPluginReportsOrder(t *testing.T) {
+	defer func(dir string) { rootDirectory = dir }(rootDirectory)
+	rootDirectory = t.TempDir()
+	for _, dir := range []string{"etc", "usr/share/holo/active"} {
+		must(t, os.MkdirAll(filepath.Join(rootDirectory, dir), 0755))
+	}
+	must(t, os.WriteFile(filepath.Join(rootDirectory, "etc/hostname"), []byte("web-1\n"), 0644))
+
+	pluginDir := t.TempDir()
+	executablePath := filepath.Join(pluginDir, "holo-active")
+	must(t, os.WriteFile(executablePath, []byte("#!/bin/sh\nprintf 'MIN_API_VERSION=3\\nMAX_API_VERSION=3\\n'\n"), 0755))
+	holorc := "plugin missing=" + filepath.Join(pluginDir, "holo-missing") + "\n" +
+		"plugin active=" + executablePath + "\n" +
+		"plugin conditional=" + executablePath + " if-hostname=db-*\n"
+	must(t, os.WriteFile(filepath.Join(rootDirectory, "etc/holorc"), []byte(holorc), 0644))
+
+	WithCacheDirectory(func() int {
+		config := ReadConfiguration()
+		if config == nil {
+			t.Fatal("ReadConfiguration failed")
+		}
+		if len(config.Plugins) != 1 || config.Plugins[0].ID() != "active" {
+			t.Errorf("expected only the active plugin to be used, got %#v", config.Plugins)
+		}
+
+		//skipped plugins are reported in the order of the plugin lines, too
+		expected := []struct {
+			id      string
+			skipped bool
+			reason  string
+		}{
+			{"missing", true, "executable not found"},
+			{"active", false, ""},
+			{"conditional", true, "condition if-hostname=db-* does not hold"},
+		}
+		reports := config.PluginReports()
+		if len(reports) != len(expected) {
+			t.Fatalf("expected %d plugin reports, got %#v", len(expected), reports)
+		}
+		for idx, e := range expected {
+			r := reports[idx]
+			if r.ID != e.id || r.Skipped != e.skipped || r.SkipReason != e.reason {
+				t.Errorf("expected report %d to be for %s (skipped = %t, reason = %q), got %#v", idx, e.id, e.skipped, e.reason, r)
+			}
+		}
+		return 0
+	})
+}
@@ -0,0 +1,149 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import (
+	"container/heap"
+	"fmt"
+	"strings"
+)
+
+// Requires returns the IDs of the entities that this entity depends on, as
+// declared by REQUIRES lines in the scan report.
+func (e *Entity) Requires() []string { return e.requires }
+
+// BlocksDependents returns whether an entity with this outcome was not
+// applied, so that the entities that require it must be skipped.
+func (o ApplyOutcome) BlocksDependents() bool {
+	switch o {
+	case ApplyFailed, ApplySkipped, ApplyDeclined:
+		return true
+	case ApplyRequiresForceToRestore:
+		//e.g. a deleted user that is not created again without --force
+		return true
+	default:
+		return false
+	}
+}
+
+// OrderByDependencies sorts the given entities such that each entity comes
+// after all entities that it requires. Dependencies on entities that are not
+// in the given list are ignored. Apart from that, the original order is
+// retained as far as possible. If the dependencies contain a cycle, an error
+// is returned.
+func OrderByDependencies(entities []*Entity) ([]*Entity, error) {
+	indexByID := make(map[string]int, len(entities))
+	for idx, entity := range entities {
+		indexByID[entity.id] = idx
+	}
+
+	//build dependency graph (as lists of dependents, plus counters of
+	//unresolved dependencies)
+	dependents := make([][]int, len(entities))
+	unresolvedCount := make([]int, len(entities))
+	for idx, entity := range entities {
+		isSeen := make(map[int]bool)
+		for _, requiredID := range entity.requires {
+			requiredIdx, exists := indexByID[requiredID]
+			if !exists || isSeen[requiredIdx] {
+				continue
+			}
+			isSeen[requiredIdx] = true
+			dependents[requiredIdx] = append(dependents[requiredIdx], idx)
+			unresolvedCount[idx]++
+		}
+	}
+
+	//Kahn's algorithm: always take the entity with the lowest original index
+	//among those whose dependencies are all resolved
+	ready := &intHeap{}
+	for idx := range entities {
+		if unresolvedCount[idx] == 0 {
+			heap.Push(ready, idx)
+		}
+	}
+	result := make([]*Entity, 0, len(entities))
+	for ready.Len() > 0 {
+		idx := heap.Pop(ready).(int)
+		result = append(result, entities[idx])
+		for _, dependentIdx := range dependents[idx] {
+			unresolvedCount[dependentIdx]--
+			if unresolvedCount[dependentIdx] == 0 {
+				heap.Push(ready, dependentIdx)
+			}
+		}
+	}
+
+	if len(result) < len(entities) {
+		return nil, fmt.Errorf("dependency cycle: %s", strings.Join(findCycle(entities, indexByID, unresolvedCount), " -> "))
+	}
+	return result, nil
+}
+
+// findCycle returns the IDs of the entities forming a dependency cycle, with
+// the first entity repeated at the end. It only considers entities that were
+// left unresolved by OrderByDependencies(), all of which are on a cycle or
+// depend on one.
+func findCycle(entities []*Entity, indexByID map[string]int, unresolvedCount []int) []string {
+	//start anywhere and follow unresolved dependencies until we end up at an
+	//entity that we have visited before
+	var path []int
+	positionInPath := make(map[int]int)
+	for idx := range entities {
+		if unresolvedCount[idx] > 0 {
+			path = append(path, idx)
+			break
+		}
+	}
+	for {
+		idx := path[len(path)-1]
+		positionInPath[idx] = len(path) - 1
+		for _, requiredID := range entities[idx].requires {
+			requiredIdx, exists := indexByID[requiredID]
+			if !exists || unresolvedCount[requiredIdx] == 0 {
+				continue
+			}
+			if pos, visited := positionInPath[requiredIdx]; visited {
+				cycle := make([]string, 0, len(path)-pos+1)
+				for _, cycleIdx := range path[pos:] {
+					cycle = append(cycle, entities[cycleIdx].id)
+				}
+				return append(cycle, entities[requiredIdx].id)
+			}
+			path = append(path, requiredIdx)
+			break
+		}
+	}
+}
+
+// intHeap implements heap.Interface for OrderByDependencies().
+type intHeap []int
+
+func (h intHeap) Len() int            { return len(h) }
+func (h intHeap) Less(i, j int) bool  { return h[i] < h[j] }
+func (h intHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *intHeap) Push(x interface{}) { *h = append(*h, x.(int)) }
+func (h *intHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
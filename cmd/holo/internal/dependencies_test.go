@@ -0,0 +1,105 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import (
+	"strings"
+	"testing"
+)
+
+func makeEntitiesForDependencyTest(specs ...string) []*Entity {
+	//each spec looks like "id" or "id:required1,required2"
+	var entities []*Entity
+	for _, spec := range specs {
+		fields := strings.SplitN(spec, ":", 2)
+		entity := &Entity{id: fields[0]}
+		if len(fields) > 1 {
+			entity.requires = strings.Split(fields[1], ",")
+		}
+		entities = append(entities, entity)
+	}
+	return entities
+}
+
+func entityIDs(entities []*Entity) string {
+	ids := make([]string, len(entities))
+	for idx, entity := range entities {
+		ids[idx] = entity.id
+	}
+	return strings.Join(ids, " ")
+}
+
+func TestOrderByDependencies(t *testing.T) {
+	testcases := []struct {
+		input    []string
+		expected string
+	}{
+		//without dependencies, the order is retained
+		{[]string{"a", "b", "c"}, "a b c"},
+		//dependencies on unknown entities are ignored
+		{[]string{"a:x", "b", "c:y"}, "a b c"},
+		//dependencies move entities backwards, but not further than necessary
+		{[]string{"a:c", "b", "c", "d"}, "b c a d"},
+		{[]string{"key:user", "file", "user:group", "group"}, "file group user key"},
+		//duplicate dependencies are okay
+		{[]string{"a:b,b", "b"}, "b a"},
+	}
+	for _, tc := range testcases {
+		result, err := OrderByDependencies(makeEntitiesForDependencyTest(tc.input...))
+		if err != nil {
+			t.Errorf("unexpected error for %v: %s", tc.input, err.Error())
+			continue
+		}
+		if actual := entityIDs(result); actual != tc.expected {
+			t.Errorf("expected order %q for %v, got %q", tc.expected, tc.input, actual)
+		}
+	}
+}
+
+func TestOrderByDependenciesWithCycle(t *testing.T) {
+	entities := makeEntitiesForDependencyTest("a", "b:d", "c:b", "d:c", "e:b")
+	_, err := OrderByDependencies(entities)
+	if err == nil {
+		t.Fatal("expected error for dependency cycle, got nil")
+	}
+	expected := "dependency cycle: b -> d -> c -> b"
+	if err.Error() != expected {
+		t.Errorf("expected error %q, got %q", expected, err.Error())
+	}
+}
+
+func TestBlocksDependents(t *testing.T) {
+	expected := map[ApplyOutcome]bool{
+		ApplyChanged:                  false,
+		ApplyUnchanged:                false,
+		ApplyRequiresForceToOverwrite: false,
+		ApplyRequiresForceToRestore:   true,
+		ApplyFailed:                   true,
+		ApplyUnknown:                  false,
+		ApplySkipped:                  true,
+		ApplyDeclined:                 true,
+	}
+	for outcome, blocks := range expected {
+		if actual := outcome.BlocksDependents(); actual != blocks {
+			t.Errorf("expected BlocksDependents() = %t for %s, got %t", blocks, outcome, actual)
+		}
+	}
+}
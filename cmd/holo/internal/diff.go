@@ -0,0 +1,632 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+package impl
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DefaultDiffContextLines is the number of context lines shown around each
+// change in a diff, unless changed with SetDiffContextLines().
+const DefaultDiffContextLines = 3
+
+var diffContextLines = DefaultDiffContextLines
+
+// SetDiffContextLines sets the number of context lines that RenderDiff()
+// shows around each change.
+func SetDiffContextLines(n int) {
+	diffContextLines = n
+}
+
+// DiffVersions lists the versions of an entity that can be compared with
+// Entity.Diff(), in the order in which they usually occur during the lifecycle
+// of an entity.
+var DiffVersions = []string{"base", "provisioned", "desired", "current"}
+
+// ParseDiffVersions parses the argument of `holo diff --between`, a pair of
+// versions like "desired,current".
+func ParseDiffVersions(spec string) (fromVersion, toVersion string, err error) {
+	fields := strings.Split(spec, ",")
+	if len(fields) != 2 {
+		return "", "", fmt.Errorf("expected two versions separated by a comma, got %q", spec)
+	}
+	for _, field := range fields {
+		if !isDiffVersion(field) {
+			return "", "", fmt.Errorf("unknown version %q (valid versions are: %s)", field, strings.Join(DiffVersions, ", "))
+		}
+	}
+	return fields[0], fields[1], nil
+}
+
+func isDiffVersion(version string) bool {
+	for _, v := range DiffVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// the same heuristic as in git: files with a NUL byte in their first few
+// kilobytes are considered binary
+const binaryDetectionLength = 8000
+
+// git-compatible file modes (as used in "new file mode" lines etc.)
+const (
+	diffModeRegular    = 0100644
+	diffModeExecutable = 0100755
+	diffModeSymlink    = 0120000
+)
+
+// diffSide is one side of a file diff.
+type diffSide struct {
+	exists   bool
+	mode     uint32
+	name     string //as shown in the "---" and "+++" lines
+	contents []byte //for symlinks: the link target
+}
+
+// readDiffSide reads a file (as returned by checkFile()) for diffing.
+func readDiffSide(pathToUse, pathToDisplay string) (diffSide, error) {
+	if pathToUse == "/dev/null" {
+		return diffSide{name: "/dev/null"}, nil
+	}
+
+	info, err := os.Lstat(pathToUse)
+	if err != nil {
+		return diffSide{}, err
+	}
+	side := diffSide{exists: true, name: pathToDisplay}
+
+	if (info.Mode() & os.ModeType) == os.ModeSymlink {
+		target, err := os.Readlink(pathToUse)
+		if err != nil {
+			return diffSide{}, err
+		}
+		side.mode = diffModeSymlink
+		side.contents = []byte(target)
+		return side, nil
+	}
+
+	side.contents, err = os.ReadFile(pathToUse)
+	if err != nil {
+		return diffSide{}, err
+	}
+	side.mode = diffModeRegular
+	if info.Mode()&0100 != 0 {
+		side.mode = diffModeExecutable
+	}
+	return side, nil
+}
+
+func (s diffSide) isBinary() bool {
+	data := s.contents
+	if len(data) > binaryDetectionLength {
+		data = data[:binaryDetectionLength]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+// DiffStat summarizes the changes in a diff (as shown by `holo diff --stat`).
+type DiffStat struct {
+	Insertions int
+	Deletions  int
+	Binary     bool
+}
+
+func (s *DiffStat) add(other DiffStat) {
+	s.Insertions += other.Insertions
+	s.Deletions += other.Deletions
+	s.Binary = s.Binary || other.Binary
+}
+
+// writeFileDiff writes a git-style diff between two files into the buffer.
+// Nothing is written if both sides are identical.
+func writeFileDiff(buf *bytes.Buffer, header string, from, to diffSide, contextLines int) (stat DiffStat) {
+	//like git, show a change of file type as a deletion plus a creation
+	if from.exists && to.exists && (from.mode == diffModeSymlink) != (to.mode == diffModeSymlink) {
+		stat = writeFileDiff(buf, header, from, diffSide{name: "/dev/null"}, contextLines)
+		stat.add(writeFileDiff(buf, header, diffSide{name: "/dev/null"}, to, contextLines))
+		return stat
+	}
+
+	var modeLines string
+	switch {
+	case !from.exists && !to.exists:
+		return stat
+	case !from.exists:
+		modeLines = fmt.Sprintf("new file mode %06o\n", to.mode)
+	case !to.exists:
+		modeLines = fmt.Sprintf("deleted file mode %06o\n", from.mode)
+	case from.mode != to.mode:
+		modeLines = fmt.Sprintf("old mode %06o\nnew mode %06o\n", from.mode, to.mode)
+	}
+
+	sameContents := bytes.Equal(from.contents, to.contents)
+	if sameContents && modeLines == "" {
+		return stat
+	}
+	buf.WriteString(header)
+	buf.WriteString(modeLines)
+	if sameContents {
+		return stat
+	}
+
+	if from.isBinary() || to.isBinary() {
+		fmt.Fprintf(buf, "Binary files %s and %s differ\n", from.name, to.name)
+		stat.Binary = true
+		return stat
+	}
+	fmt.Fprintf(buf, "--- %s\n+++ %s\n", from.name, to.name)
+	stat.Insertions, stat.Deletions = writeHunks(buf, splitLines(from.contents), splitLines(to.contents), contextLines)
+	return stat
+}
+
+// splitLines splits a file into lines. Each line retains its trailing newline
+// character (if any), so that a missing newline at the end of the file shows
+// up as a difference.
+func splitLines(data []byte) []string {
+	var lines []string
+	for len(data) > 0 {
+		idx := bytes.IndexByte(data, '\n')
+		if idx < 0 {
+			lines = append(lines, string(data))
+			break
+		}
+		lines = append(lines, string(data[:idx+1]))
+		data = data[idx+1:]
+	}
+	return lines
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// line diff
+
+// diffChange describes a run of removed lines a[i1:i1+chg1] that is replaced
+// by the inserted lines b[i2:i2+chg2].
+type diffChange struct {
+	i1, chg1 int
+	i2, chg2 int
+}
+
+// diffLines computes the changes between two lists of lines.
+func diffLines(a, b []string) []diffChange {
+	removed := make([]bool, len(a))
+	inserted := make([]bool, len(b))
+
+	//lines that do not appear in the other file at all are certainly changed;
+	//leaving them out speeds up the diff considerably for very different files
+	aKept, aIndex := keepMatchableLines(a, b, removed)
+	bKept, bIndex := keepMatchableLines(b, a, inserted)
+	d := lineDiffer{
+		a:        aKept,
+		b:        bKept,
+		removed:  make([]bool, len(aKept)),
+		inserted: make([]bool, len(bKept)),
+	}
+	d.compare(0, len(aKept), 0, len(bKept))
+	for idx, isRemoved := range d.removed {
+		removed[aIndex[idx]] = isRemoved
+	}
+	for idx, isInserted := range d.inserted {
+		inserted[bIndex[idx]] = isInserted
+	}
+
+	compactChanges(removed, a, inserted)
+	compactChanges(inserted, b, removed)
+
+	var changes []diffChange
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		if i < len(a) && j < len(b) && !removed[i] && !inserted[j] {
+			i++
+			j++
+			continue
+		}
+		c := diffChange{i1: i, i2: j}
+		for i < len(a) && removed[i] {
+			i++
+		}
+		for j < len(b) && inserted[j] {
+			j++
+		}
+		c.chg1, c.chg2 = i-c.i1, j-c.i2
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+// keepMatchableLines returns those lines that also appear in the other file
+// (and their original indices), and marks all other lines as changed.
+func keepMatchableLines(lines, otherLines []string, changed []bool) (kept []string, index []int) {
+	inOther := make(map[string]bool, len(otherLines))
+	for _, line := range otherLines {
+		inOther[line] = true
+	}
+	for idx, line := range lines {
+		if inOther[line] {
+			kept = append(kept, line)
+			index = append(index, idx)
+		} else {
+			changed[idx] = true
+		}
+	}
+	return kept, index
+}
+
+// lineDiffer implements the Myers diff algorithm (in its linear-space
+// variant) on two lists of lines.
+type lineDiffer struct {
+	a, b              []string
+	removed, inserted []bool
+}
+
+func (d *lineDiffer) compare(aLo, aHi, bLo, bHi int) {
+	//skip common prefix and suffix
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		aLo++
+		bLo++
+	}
+	for aLo < aHi && bLo < bHi && d.a[aHi-1] == d.b[bHi-1] {
+		aHi--
+		bHi--
+	}
+
+	switch {
+	case aLo == aHi:
+		for j := bLo; j < bHi; j++ {
+			d.inserted[j] = true
+		}
+	case bLo == bHi:
+		for i := aLo; i < aHi; i++ {
+			d.removed[i] = true
+		}
+	default:
+		x, y, ok := d.bisect(aLo, aHi, bLo, bHi)
+		if ok {
+			d.compare(aLo, x, bLo, y)
+			d.compare(x, aHi, y, bHi)
+			return
+		}
+		//no common lines at all
+		for i := aLo; i < aHi; i++ {
+			d.removed[i] = true
+		}
+		for j := bLo; j < bHi; j++ {
+			d.inserted[j] = true
+		}
+	}
+}
+
+// bisect finds the point where a forward and a reverse shortest edit path
+// through the given ranges meet, and returns it as an absolute position.
+func (d *lineDiffer) bisect(aLo, aHi, bLo, bHi int) (x, y int, ok bool) {
+	n, m := aHi-aLo, bHi-bLo
+	maxD := (n + m + 1) / 2
+	offset := maxD + 1
+	vf := make([]int, 2*offset+1)
+	vr := make([]int, 2*offset+1)
+	for idx := range vf {
+		vf[idx] = -1
+		vr[idx] = -1
+	}
+	vf[offset+1] = 0
+	vr[offset+1] = 0
+
+	delta := n - m
+	//if the total number of lines is odd, the paths meet during the forward
+	//pass, otherwise during the reverse pass
+	front := delta%2 != 0
+	kfStart, kfEnd, krStart, krEnd := 0, 0, 0, 0
+
+	for dist := 0; dist < maxD; dist++ {
+		//forward pass
+		for k := -dist + kfStart; k <= dist-kfEnd; k += 2 {
+			var x1 int
+			if k == -dist || (k != dist && vf[offset+k-1] < vf[offset+k+1]) {
+				x1 = vf[offset+k+1]
+			} else {
+				x1 = vf[offset+k-1] + 1
+			}
+			y1 := x1 - k
+			for x1 < n && y1 < m && d.a[aLo+x1] == d.b[bLo+y1] {
+				x1++
+				y1++
+			}
+			vf[offset+k] = x1
+			switch {
+			case x1 > n:
+				kfEnd += 2
+			case y1 > m:
+				kfStart += 2
+			case front:
+				kr := delta - k
+				if kr >= -offset && kr <= offset && vr[offset+kr] != -1 {
+					if x1 >= n-vr[offset+kr] {
+						return aLo + x1, bLo + y1, true
+					}
+				}
+			}
+		}
+
+		//reverse pass
+		for k := -dist + krStart; k <= dist-krEnd; k += 2 {
+			var x2 int
+			if k == -dist || (k != dist && vr[offset+k-1] < vr[offset+k+1]) {
+				x2 = vr[offset+k+1]
+			} else {
+				x2 = vr[offset+k-1] + 1
+			}
+			y2 := x2 - k
+			for x2 < n && y2 < m && d.a[aHi-x2-1] == d.b[bHi-y2-1] {
+				x2++
+				y2++
+			}
+			vr[offset+k] = x2
+			switch {
+			case x2 > n:
+				krEnd += 2
+			case y2 > m:
+				krStart += 2
+			case !front:
+				kf := delta - k
+				if kf >= -offset && kf <= offset && vf[offset+kf] != -1 {
+					x1 := vf[offset+kf]
+					if x1 >= n-x2 {
+						return aLo + x1, bLo + x1 - kf, true
+					}
+				}
+			}
+		}
+	}
+
+	return 0, 0, false
+}
+
+// compactChanges slides groups of changed lines in one file into a canonical
+// position, in the same way as git's xdiff does (minus the indent heuristic):
+// Each group is moved down as far as possible, unless it can be aligned with
+// a group of changes in the other file.
+func compactChanges(changed []bool, lines []string, otherChanged []bool) {
+	isChanged := func(flags []bool, idx int) bool {
+		return idx >= 0 && idx < len(flags) && flags[idx]
+	}
+
+	//a group is a (possibly empty) run of changed lines [start,end); between
+	//two groups, there is exactly one unchanged line
+	type group struct{ start, end int }
+	initGroup := func(flags []bool) group {
+		g := group{}
+		for isChanged(flags, g.end) {
+			g.end++
+		}
+		return g
+	}
+	next := func(flags []bool, g *group) bool {
+		if g.end >= len(flags) {
+			return false
+		}
+		g.start = g.end + 1
+		g.end = g.start
+		for isChanged(flags, g.end) {
+			g.end++
+		}
+		return true
+	}
+	previous := func(flags []bool, g *group) bool {
+		if g.start == 0 {
+			return false
+		}
+		g.end = g.start - 1
+		g.start = g.end
+		for isChanged(flags, g.start-1) {
+			g.start--
+		}
+		return true
+	}
+	slideDown := func(g *group) bool {
+		if g.end < len(lines) && lines[g.start] == lines[g.end] {
+			changed[g.start] = false
+			changed[g.end] = true
+			g.start++
+			g.end++
+			for isChanged(changed, g.end) {
+				g.end++
+			}
+			return true
+		}
+		return false
+	}
+	slideUp := func(g *group) bool {
+		if g.start > 0 && lines[g.start-1] == lines[g.end-1] {
+			g.start--
+			g.end--
+			changed[g.start] = true
+			changed[g.end] = false
+			for isChanged(changed, g.start-1) {
+				g.start--
+			}
+			return true
+		}
+		return false
+	}
+
+	g := initGroup(changed)
+	og := initGroup(otherChanged)
+	for {
+		if g.end != g.start {
+			var earliestEnd, endMatchingOther int
+			for {
+				size := g.end - g.start
+				endMatchingOther = -1
+				//shift the group up as far as possible (this may merge it
+				//with previous groups)
+				for slideUp(&g) {
+					previous(otherChanged, &og)
+				}
+				earliestEnd = g.end
+				if og.end > og.start {
+					endMatchingOther = g.end
+				}
+				//then shift it down as far as possible
+				for slideDown(&g) {
+					next(otherChanged, &og)
+					if og.end > og.start {
+						endMatchingOther = g.end
+					}
+				}
+				if size == g.end-g.start {
+					break
+				}
+			}
+			//if possible, align the group with a group in the other file
+			if g.end != earliestEnd && endMatchingOther != -1 {
+				for og.end == og.start {
+					slideUp(&g)
+					previous(otherChanged, &og)
+				}
+			}
+		}
+
+		if !next(changed, &g) {
+			break
+		}
+		next(otherChanged, &og)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// hunk rendering
+
+// writeHunks writes the hunks of a unified diff between a and b (without file
+// headers) into the buffer, and returns the number of inserted and deleted lines.
+func writeHunks(buf *bytes.Buffer, a, b []string, contextLines int) (insertions, deletions int) {
+	changes := diffLines(a, b)
+	funcLine := ""
+	funcLineSearchLimit := -1
+
+	for len(changes) > 0 {
+		//collect changes that are close enough to share a hunk
+		count := 1
+		for count < len(changes) {
+			last := changes[count-1]
+			if changes[count].i1-(last.i1+last.chg1) > 2*contextLines {
+				break
+			}
+			count++
+		}
+		first, last := changes[0], changes[count-1]
+
+		s1 := maxInt(first.i1-contextLines, 0)
+		s2 := maxInt(first.i2-contextLines, 0)
+		e1 := minInt(last.i1+last.chg1+contextLines, len(a))
+		e2 := minInt(last.i2+last.chg2+contextLines, len(b))
+
+		//like git, show the closest preceding line that looks like the start
+		//of a function or section (if there is none since the previous hunk,
+		//repeat the previous one)
+		for idx := s1 - 1; idx > funcLineSearchLimit; idx-- {
+			if line, ok := funcLineCandidate(a[idx]); ok {
+				funcLine = line
+				break
+			}
+		}
+		funcLineSearchLimit = s1 - 1
+
+		buf.WriteString("@@ -")
+		buf.WriteString(hunkRange(s1, e1-s1))
+		buf.WriteString(" +")
+		buf.WriteString(hunkRange(s2, e2-s2))
+		buf.WriteString(" @@")
+		if funcLine != "" {
+			buf.WriteString(" " + funcLine)
+		}
+		buf.WriteString("\n")
+
+		pos := s1
+		for _, c := range changes[:count] {
+			writeDiffLines(buf, " ", a[pos:c.i1])
+			writeDiffLines(buf, "-", a[c.i1:c.i1+c.chg1])
+			writeDiffLines(buf, "+", b[c.i2:c.i2+c.chg2])
+			pos = c.i1 + c.chg1
+			insertions += c.chg2
+			deletions += c.chg1
+		}
+		writeDiffLines(buf, " ", a[pos:e1])
+
+		changes = changes[count:]
+	}
+	return insertions, deletions
+}
+
+func writeDiffLines(buf *bytes.Buffer, prefix string, lines []string) {
+	for _, line := range lines {
+		buf.WriteString(prefix)
+		buf.WriteString(line)
+		if !strings.HasSuffix(line, "\n") {
+			buf.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange formats one side of a hunk header, omitting the line count if it
+// is 1 (like GNU diff and git do).
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// funcLineCandidate implements git's default heuristic for the text that
+// follows the hunk header: a line that starts with a letter, "_" or "$".
+func funcLineCandidate(line string) (string, bool) {
+	if line == "" {
+		return "", false
+	}
+	c := line[0]
+	if !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c == '_' || c == '$') {
+		return "", false
+	}
+	if len(line) > 80 {
+		line = line[:80]
+	}
+	return strings.TrimRight(line, " \t\r\n\v\f"), true
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,258 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+package impl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestDiffHunks(t *testing.T) {
+	testCases := []struct {
+		from, to     string
+		contextLines int
+		expected     string
+	}{
+		{
+			from:         "a\nb\nc\n",
+			to:           "a\nB\nc\n",
+			contextLines: 3,
+			expected:     "@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			from:         "a\n",
+			to:           "b\n",
+			contextLines: 3,
+			expected:     "@@ -1 +1 @@\n-a\n+b\n",
+		},
+		{
+			from:         "",
+			to:           "a\n",
+			contextLines: 3,
+			expected:     "@@ -0,0 +1 @@\n+a\n",
+		},
+		{
+			from:         "a\nb",
+			to:           "a\nb\n",
+			contextLines: 3,
+			expected:     "@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+		{
+			//changes far apart end up in separate hunks, and the hunk header
+			//shows the closest preceding line that looks like a section start
+			from:         "one:\n1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			to:           "one:\nX\n2\n3\n4\n5\n6\n7\n8\nY\n",
+			contextLines: 2,
+			expected:     "@@ -1,4 +1,4 @@\n one:\n-1\n+X\n 2\n 3\n@@ -8,3 +8,3 @@ one:\n 7\n 8\n-9\n+Y\n",
+		},
+		{
+			from:         "name = \"x\"\n1\n2\n3\n4\n5\n",
+			to:           "name = \"x\"\n1\n2\n3\n4\n6\n",
+			contextLines: 1,
+			expected:     "@@ -5,2 +5,2 @@ name = \"x\"\n 4\n-5\n+6\n",
+		},
+		{
+			//changes that are close enough share a hunk
+			from:         "1\n2\n3\n4\n5\n",
+			to:           "X\n2\n3\n4\nY\n",
+			contextLines: 2,
+			expected:     "@@ -1,5 +1,5 @@\n-1\n+X\n 2\n 3\n 4\n-5\n+Y\n",
+		},
+		{
+			from:         "1\n2\n3\n",
+			to:           "1\n2\n2.5\n3\n",
+			contextLines: 0,
+			expected:     "@@ -2,0 +3 @@\n+2.5\n",
+		},
+		{
+			//ambiguous insertions are placed as far down as possible
+			from:         "a\nb\n",
+			to:           "a\nb\na\nb\n",
+			contextLines: 0,
+			expected:     "@@ -2,0 +3,2 @@ b\n+a\n+b\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		var buf bytes.Buffer
+		writeHunks(&buf, splitLines([]byte(tc.from)), splitLines([]byte(tc.to)), tc.contextLines)
+		if buf.String() != tc.expected {
+			t.Errorf("diff of %q and %q: expected\n%s\ngot\n%s", tc.from, tc.to, tc.expected, buf.String())
+		}
+	}
+}
+
+var colorCodeRx = regexp.MustCompile(`\x1B\[[0-9;]*m`)
+
+func TestRenderFileDiff(t *testing.T) {
+	dir := t.TempDir()
+	//make sure that paths in the test directory are not mistaken for
+	//resource paths
+	defer func(rel, abs string) {
+		virtualResourceRoot, absVirtualResourceRoot = rel, abs
+	}(virtualResourceRoot, absVirtualResourceRoot)
+	virtualResourceRoot = filepath.Join(dir, "resources")
+	absVirtualResourceRoot = virtualResourceRoot
+	write := func(name, contents string, mode os.FileMode) string {
+		path := filepath.Join(dir, name)
+		err := os.WriteFile(path, []byte(contents), mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	symlink := func(name, target string) string {
+		path := filepath.Join(dir, name)
+		err := os.Symlink(target, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	fileA := write("a", "foo\n", 0644)
+	fileB := write("b", "bar\n", 0644)
+	fileExec := write("exec", "foo\n", 0755)
+	fileBinary := write("binary", "foo\x00bar", 0644)
+	linkA := symlink("link-a", "/etc/a")
+	linkB := symlink("link-b", "/etc/b")
+	missing := filepath.Join(dir, "missing")
+
+	testCases := []struct {
+		from, to string
+		stat     DiffStat
+		expected []string
+	}{
+		{fileA, fileA, DiffStat{}, nil},
+		{missing, "/dev/null", DiffStat{}, nil},
+		{fileA, fileB, DiffStat{Insertions: 1, Deletions: 1}, []string{
+			"diff --holo " + fileA + " " + fileB,
+			"--- " + fileA,
+			"+++ " + fileB,
+			"@@ -1 +1 @@",
+			"-foo",
+			"+bar",
+		}},
+		{missing, fileA, DiffStat{Insertions: 1}, []string{
+			"diff --holo " + missing + " " + fileA,
+			"new file mode 100644",
+			"--- /dev/null",
+			"+++ " + fileA,
+			"@@ -0,0 +1 @@",
+			"+foo",
+		}},
+		{fileA, fileExec, DiffStat{}, []string{
+			"diff --holo " + fileA + " " + fileExec,
+			"old mode 100644",
+			"new mode 100755",
+		}},
+		{linkA, linkB, DiffStat{Insertions: 1, Deletions: 1}, []string{
+			"diff --holo " + linkA + " " + linkB,
+			"--- " + linkA,
+			"+++ " + linkB,
+			"@@ -1 +1 @@",
+			"-/etc/a",
+			"\\ No newline at end of file",
+			"+/etc/b",
+			"\\ No newline at end of file",
+		}},
+		{fileA, linkA, DiffStat{Insertions: 1, Deletions: 1}, []string{
+			"diff --holo " + fileA + " " + linkA,
+			"deleted file mode 100644",
+			"--- " + fileA,
+			"+++ /dev/null",
+			"@@ -1 +0,0 @@",
+			"-foo",
+			"diff --holo " + fileA + " " + linkA,
+			"new file mode 120000",
+			"--- /dev/null",
+			"+++ " + linkA,
+			"@@ -0,0 +1 @@",
+			"+/etc/a",
+			"\\ No newline at end of file",
+		}},
+		{fileA, fileBinary, DiffStat{Binary: true}, []string{
+			"diff --holo " + fileA + " " + fileBinary,
+			"Binary files " + fileA + " and " + fileBinary + " differ",
+		}},
+	}
+
+	for _, tc := range testCases {
+		result, stat, err := renderFileDiff(tc.from, tc.to)
+		if err != nil {
+			t.Errorf("unexpected error for diff of %s and %s: %s", tc.from, tc.to, err.Error())
+			continue
+		}
+		if stat != tc.stat {
+			t.Errorf("diff of %s and %s: expected stat %#v, got %#v", tc.from, tc.to, tc.stat, stat)
+		}
+		actual := colorCodeRx.ReplaceAllString(string(result), "")
+		expected := strings.Join(tc.expected, "\n")
+		if len(tc.expected) > 0 {
+			expected += "\n"
+		}
+		if actual != expected {
+			t.Errorf("diff of %s and %s: expected\n%s\ngot\n%s", tc.from, tc.to, expected, actual)
+		}
+	}
+
+	if _, _, err := renderFileDiff(dir, fileA); err == nil {
+		t.Errorf("expected error when diffing a directory, got none")
+	}
+}
+
+func TestParseDiffVersions(t *testing.T) {
+	from, to, err := ParseDiffVersions("desired,current")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	} else if from != "desired" || to != "current" {
+		t.Errorf("expected desired,current, got %s,%s", from, to)
+	}
+
+	for _, spec := range []string{"", "desired", "desired,current,base", "desired,actual"} {
+		if _, _, err := ParseDiffVersions(spec); err == nil {
+			t.Errorf("expected error for %q, got none", spec)
+		}
+	}
+}
+
+func TestParseDiffMessage(t *testing.T) {
+	testCases := []struct {
+		message  string
+		expected map[string]string
+	}{
+		{"", nil},
+		{"/a\000/b\000", map[string]string{"provisioned": "/a", "current": "/b"}},
+		{"/a\000/b\000desired\000/c\000base\000/dev/null\000", map[string]string{
+			"provisioned": "/a", "current": "/b", "desired": "/c", "base": "/dev/null",
+		}},
+	}
+	for _, tc := range testCases {
+		actual := parseDiffMessage(tc.message)
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("message %q: expected %#v, got %#v", tc.message, tc.expected, actual)
+		}
+	}
+}
@@ -22,12 +22,11 @@ package impl
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"regexp"
 	"strings"
-	"syscall"
 )
 
 // InfoLine represents a line in the information section of an Entity.
@@ -44,11 +43,36 @@ type Entity struct {
 	actionReason string
 	sourceFiles  []string
 	infoLines    []InfoLine
+	requires     []string
+	watchPaths   []string
+	tags         []string
+	outcome      *ApplyOutcome //nil until Apply(), Plan() or Skip() was called
+	outcomeError error
+	stateBefore  string //only recorded for the history (see StartHistory())
+	stateAfter   string
 }
 
 // EntityID returns a string that uniquely identifies the entity.
 func (e *Entity) EntityID() string { return e.id }
 
+// PluginID returns the ID of the plugin that provides this entity.
+func (e *Entity) PluginID() string { return e.plugin.id }
+
+// SupportsOperation returns whether the plugin that provides this entity
+// implements the given optional operation.
+func (e *Entity) SupportsOperation(operation string) bool {
+	return e.plugin.SupportsOperation(operation)
+}
+
+func (e *Entity) hasTag(tag string) bool {
+	for _, t := range e.tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
 // AllMatchingSelectors returns all selectors that this entity matches.
 func (e *Entity) AllMatchingSelectors() map[string]bool {
 	result := map[string]bool{
@@ -64,6 +88,9 @@ func (e *Entity) AllMatchingSelectors() map[string]bool {
 			result[generatorFile] = true
 		}
 	}
+	for _, tag := range e.tags {
+		result["tag:"+tag] = true
+	}
 	return result
 }
 
@@ -72,12 +99,16 @@ func (e *Entity) PrintReport(withAction bool) {
 	//print initial line with action and entity ID
 	//(note that Stdout != os.Stdout)
 	var lineFormat string
+	idFormat := "%s"
+	if UseColors(Stdout) {
+		idFormat = "\x1b[1m%s\x1b[0m"
+	}
 	if e.actionVerb == "" || !withAction {
 		lineFormat = "%12s %s\n"
-		fmt.Fprintf(Stdout, "\x1b[1m%s\x1b[0m", e.id)
+		fmt.Fprintf(Stdout, idFormat, e.id)
 	} else {
 		lineFormat = fmt.Sprintf("%%%ds %%s\n", len(e.actionVerb))
-		fmt.Fprintf(Stdout, "%s \x1b[1m%s\x1b[0m", e.actionVerb, e.id)
+		fmt.Fprintf(Stdout, "%s "+idFormat, e.actionVerb, e.id)
 	}
 	if e.actionReason == "" {
 		Stdout.Write([]byte{'\n'})
@@ -106,6 +137,15 @@ func (e *Entity) PrintScanReport() {
 	for _, sourceFile := range e.sourceFiles {
 		fmt.Fprintf(Stdout, "SOURCE: %s\n", sourceFile)
 	}
+	for _, tag := range e.tags {
+		fmt.Fprintf(Stdout, "TAG: %s\n", tag)
+	}
+	for _, requiredID := range e.requires {
+		fmt.Fprintf(Stdout, "REQUIRES: %s\n", requiredID)
+	}
+	for _, path := range e.watchPaths {
+		fmt.Fprintf(Stdout, "WATCH: %s\n", path)
+	}
 	for _, infoLine := range e.infoLines {
 		fmt.Fprintf(Stdout, "%s: %s\n", infoLine.attribute, infoLine.value)
 	}
@@ -113,40 +153,252 @@ func (e *Entity) PrintScanReport() {
 	Stdout.EndParagraph()
 }
 
+// MarshalJSON implements the json.Marshaler interface.
+func (l InfoLine) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Attribute string `json:"attribute"`
+		Value     string `json:"value"`
+	}{l.attribute, l.value})
+}
+
+// EntityReport is the machine-readable representation of an Entity, as shown
+// by `holo scan --json`.
+type EntityReport struct {
+	ID           string     `json:"id"`
+	PluginID     string     `json:"plugin"`
+	ActionVerb   string     `json:"action_verb,omitempty"`
+	ActionReason string     `json:"action_reason,omitempty"`
+	SourceFiles  []string   `json:"source_files"`
+	InfoLines    []InfoLine `json:"info"`
+	Requires     []string   `json:"requires"`
+	WatchPaths   []string   `json:"watch"`
+	Tags         []string   `json:"tags"`
+}
+
+// Report returns the machine-readable representation of this Entity. Like
+// PrintScanReport, it omits the default action verb.
+func (e *Entity) Report() EntityReport {
+	r := EntityReport{
+		ID:           e.id,
+		PluginID:     e.plugin.id,
+		ActionVerb:   e.actionVerb,
+		ActionReason: e.actionReason,
+		SourceFiles:  e.sourceFiles,
+		InfoLines:    e.infoLines,
+		Requires:     e.requires,
+		WatchPaths:   e.watchPaths,
+		Tags:         e.tags,
+	}
+	if r.ActionVerb == "Working on" && r.ActionReason == "" {
+		r.ActionVerb = ""
+	}
+	//always render lists as arrays, never as null
+	if r.SourceFiles == nil {
+		r.SourceFiles = []string{}
+	}
+	if r.InfoLines == nil {
+		r.InfoLines = []InfoLine{}
+	}
+	if r.Requires == nil {
+		r.Requires = []string{}
+	}
+	if r.WatchPaths == nil {
+		r.WatchPaths = []string{}
+	}
+	if r.Tags == nil {
+		r.Tags = []string{}
+	}
+	return r
+}
+
+// ApplyOutcome describes the result of Entity.Apply(), as reported by the
+// plugin on file descriptor 3.
+type ApplyOutcome int
+
+const (
+	//ApplyChanged means that the entity was provisioned.
+	ApplyChanged ApplyOutcome = iota
+	//ApplyUnchanged means that the plugin reported "not changed".
+	ApplyUnchanged
+	//ApplyRequiresForceToOverwrite means that the plugin reported "requires --force to overwrite".
+	ApplyRequiresForceToOverwrite
+	//ApplyRequiresForceToRestore means that the plugin reported "requires --force to restore".
+	ApplyRequiresForceToRestore
+	//ApplyFailed means that the plugin could not be executed or exited with an error.
+	ApplyFailed
+	//ApplyUnknown means that the plugin cannot plan changes, so the outcome of
+	//Entity.Plan() is not known.
+	ApplyUnknown
+	//ApplySkipped means that the entity was not applied because an entity that
+	//it requires was not applied (because it failed, was skipped or declined
+	//itself, or requires --force to be restored).
+	ApplySkipped
+	//ApplyDeclined means that the user chose not to apply the entity in
+	//`holo apply --interactive`.
+	ApplyDeclined
+)
+
+// String returns the identifier used for this outcome in machine-readable output.
+func (o ApplyOutcome) String() string {
+	switch o {
+	case ApplyChanged:
+		return "changed"
+	case ApplyUnchanged:
+		return "unchanged"
+	case ApplyRequiresForceToOverwrite:
+		return "requires-force-to-overwrite"
+	case ApplyRequiresForceToRestore:
+		return "requires-force-to-restore"
+	case ApplyUnknown:
+		return "unknown"
+	case ApplySkipped:
+		return "skipped"
+	case ApplyDeclined:
+		return "declined"
+	default:
+		return "failed"
+	}
+}
+
+// ApplyReport is the machine-readable representation of the result of
+// Entity.Apply(), as shown by `holo apply --json`.
+type ApplyReport struct {
+	EntityReport
+	Outcome string `json:"outcome"`
+	Error   string `json:"error,omitempty"`
+}
+
+// NewApplyReport combines the return values of Entity.Apply() into an ApplyReport.
+func (e *Entity) NewApplyReport(outcome ApplyOutcome, err error) ApplyReport {
+	r := ApplyReport{EntityReport: e.Report(), Outcome: outcome.String()}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	return r
+}
+
 // Apply performs the complete application algorithm for the given Entity.
-func (e *Entity) Apply(withForce bool) {
+// Errors are reported on stderr immediately, but are also returned for the
+// benefit of callers that produce machine-readable output.
+func (e *Entity) Apply(withForce bool) (ApplyOutcome, error) {
 	command := "apply"
 	if withForce {
 		command = "force-apply"
 	}
+	outcome, err := e.runApplyOperation(command)
+	//the hashes reported by the plugin are only recorded for changed entities
+	if outcome != ApplyChanged {
+		e.stateBefore, e.stateAfter = "", ""
+	}
+	if journalErr := RecordInJournal(e); journalErr != nil {
+		Errorf(Stderr, "cannot record %s in journal: %s", e.id, journalErr.Error())
+	}
+	return e.recordOutcome(outcome, err)
+}
 
+// Plan is the dry-run variant of Apply. It runs the "plan" operation, which
+// makes the plugin describe the changes that Apply would make without making
+// them. If the plugin does not support the "plan" operation, ApplyUnknown is
+// returned.
+func (e *Entity) Plan(withForce bool) (ApplyOutcome, error) {
+	if !e.plugin.SupportsOperation("plan") {
+		e.PrintReport(true)
+		Warnf(Stderr, "Cannot plan changes: plugin %s does not support dry runs", e.plugin.ID())
+		return e.recordOutcome(ApplyUnknown, nil)
+	}
+	command := "plan"
+	if withForce {
+		command = "force-plan"
+	}
+	return e.recordOutcome(e.runApplyOperation(command))
+}
+
+// Rollback restores the state of this entity before it was changed by a
+// previous run of `holo apply`, as recorded in the journal of that run. It
+// runs the "rollback" operation, so the outcomes are the same as for Apply(),
+// except that --force is never required.
+func (e *Entity) Rollback() (ApplyOutcome, error) {
+	if !e.plugin.SupportsOperation("rollback") {
+		e.PrintReport(true)
+		err := fmt.Errorf("plugin %s does not support rollbacks", e.plugin.ID())
+		Errorf(Stderr, "Cannot roll back: %s", err.Error())
+		return e.recordOutcome(ApplyFailed, err)
+	}
+	return e.recordOutcome(e.runApplyOperation("rollback"))
+}
+
+// Skip reports that the entity is not applied because the entity with the
+// given ID, which this entity requires, was not applied. The reported error is
+// also returned for the benefit of callers that produce machine-readable
+// output.
+func (e *Entity) Skip(requiredID string) error {
+	e.PrintReport(true)
+	err := fmt.Errorf("skipped because required entity %s was not applied", requiredID)
+	Errorf(Stderr, "Skipped because required entity %s was not applied", requiredID)
+	e.recordOutcome(ApplySkipped, err)
+	return err
+}
+
+// Decline records that the user chose not to apply the entity. Nothing is
+// printed since the entity was just shown to the user by Review().
+func (e *Entity) Decline() (ApplyOutcome, error) {
+	return e.recordOutcome(ApplyDeclined, nil)
+}
+
+// Outcome returns the outcome of the last call to Apply(), Plan(), Skip() or
+// Decline(), or false if none of these has been called yet.
+func (e *Entity) Outcome() (ApplyOutcome, bool) {
+	if e.outcome == nil {
+		return 0, false
+	}
+	return *e.outcome, true
+}
+
+func (e *Entity) recordOutcome(outcome ApplyOutcome, err error) (ApplyOutcome, error) {
+	e.outcome = &outcome
+	e.outcomeError = err
+	return outcome, err
+}
+
+// runApplyOperation runs an operation that reports its outcome like the
+// "apply" operation (i.e. apply, force-apply, plan, force-plan or rollback).
+func (e *Entity) runApplyOperation(command string) (ApplyOutcome, error) {
 	//track whether the report was already printed
 	tracker := &PrologueTracker{Printer: func() { e.PrintReport(true) }}
 	stdout := &PrologueWriter{Tracker: tracker, Writer: Stdout}
 	stderr := &PrologueWriter{Tracker: tracker, Writer: Stderr}
 
-	//execute apply operation
+	//execute apply (or similar) operation
 	cmdText, err := e.plugin.RunCommandWithFD3([]string{command, e.id}, stdout, stderr)
 	if err != nil {
 		Errorf(stderr, err.Error())
-		return
+		return ApplyFailed, err
 	}
 
 	//only print report if there was output, or if the plugin provisioned the
 	//entity (as signaled by the absence of the "not changed\n" command")
+	outcome := ApplyChanged
 	showReport := true
 	showDiff := false
-	if err == nil {
-		cmdLines := strings.Split(cmdText, "\n")
-		for _, line := range cmdLines {
-			switch line {
-			case "not changed":
-				showReport = false
-			case "requires --force to overwrite":
-				Errorf(stderr, "Entity has been modified by user (use --force to overwrite)")
-				showDiff = true
-			case "requires --force to restore":
-				Errorf(stderr, "Entity has been deleted by user (use --force to restore)")
+	cmdLines := strings.Split(cmdText, "\n")
+	for _, line := range cmdLines {
+		switch line {
+		case "not changed":
+			outcome = ApplyUnchanged
+			showReport = false
+		case "requires --force to overwrite":
+			Errorf(stderr, "Entity has been modified by user (use --force to overwrite)")
+			outcome = ApplyRequiresForceToOverwrite
+			showDiff = true
+		case "requires --force to restore":
+			Errorf(stderr, "Entity has been deleted by user (use --force to restore)")
+			outcome = ApplyRequiresForceToRestore
+		default:
+			//hashes of the actual state for the history (see FinishHistory())
+			if strings.HasPrefix(line, "state before: ") {
+				e.stateBefore = strings.TrimPrefix(line, "state before: ")
+			} else if strings.HasPrefix(line, "state after: ") {
+				e.stateAfter = strings.TrimPrefix(line, "state after: ")
 			}
 		}
 	}
@@ -157,7 +409,7 @@ func (e *Entity) Apply(withForce bool) {
 		diff, err := e.RenderDiff()
 		if err != nil {
 			Errorf(stderr, err.Error())
-			return
+			return outcome, err
 		}
 		//indent diff
 		indent := []byte("    ")
@@ -168,6 +420,14 @@ func (e *Entity) Apply(withForce bool) {
 		Stdout.EndParagraph()
 		Stdout.Write(diff)
 	}
+	return outcome, nil
+}
+
+// Diff is the result of Entity.Diff().
+type Diff struct {
+	//colorized unified diff (empty if the versions are identical)
+	Output []byte
+	Stat   DiffStat
 }
 
 // RenderDiff creates a unified diff of a target file and its last provisioned
@@ -175,68 +435,98 @@ func (e *Entity) Apply(withForce bool) {
 // handles symlinks and missing files gracefully. The output is always a patch
 // that can be applied to last provisioned version into the current version.
 func (e *Entity) RenderDiff() ([]byte, error) {
-	cmdText, err := e.plugin.RunCommandWithFD3([]string{"diff", e.id}, Stdout, Stderr)
+	diff, err := e.Diff("provisioned", "current")
+	if err != nil || diff == nil {
+		return nil, err
+	}
+	return diff.Output, nil
+}
+
+// Diff creates a unified diff between two versions of this entity (as listed
+// in DiffVersions). If the plugin does not know how to diff this entity, nil
+// is returned.
+func (e *Entity) Diff(fromVersion, toVersion string) (*Diff, error) {
+	//the other versions are only requested when needed since they may be
+	//expensive to compute (e.g. for files, "desired" runs all holoscripts)
+	args := []string{"diff", e.id}
+	if fromVersion != "provisioned" || toVersion != "current" {
+		args = append(args, fromVersion, toVersion)
+	}
+	cmdText, err := e.plugin.RunCommandWithFD3(args, Stdout, Stderr)
 	if err != nil {
 		return nil, err
 	}
 
 	//were paths given for diffing? if not, that's okay, not every plugin knows
 	//how to diff
-	cmdLines := strings.Split(cmdText, "\000")
-	if len(cmdLines) < 2 {
+	paths := parseDiffMessage(cmdText)
+	if paths == nil {
 		return nil, nil
 	}
+	fromPath, ok := paths[fromVersion]
+	if !ok {
+		return nil, fmt.Errorf("plugin %s cannot provide the %s version", e.plugin.ID(), fromVersion)
+	}
+	toPath, ok := paths[toVersion]
+	if !ok {
+		return nil, fmt.Errorf("plugin %s cannot provide the %s version", e.plugin.ID(), toVersion)
+	}
 
-	return renderFileDiff(cmdLines[0], cmdLines[1])
+	output, stat, err := renderFileDiff(fromPath, toPath)
+	if err != nil {
+		return nil, err
+	}
+	return &Diff{Output: output, Stat: stat}, nil
 }
 
-func renderFileDiff(fromPath, toPath string) ([]byte, error) {
+// parseDiffMessage parses the message of the "diff" operation: the paths of
+// the last provisioned and of the current version of the entity, optionally
+// followed by pairs of version name and path for other versions.
+func parseDiffMessage(cmdText string) map[string]string {
+	fields := strings.Split(cmdText, "\000")
+	if len(fields) < 2 {
+		return nil
+	}
+	paths := map[string]string{"provisioned": fields[0], "current": fields[1]}
+	for idx := 2; idx+1 < len(fields); idx += 2 {
+		paths[fields[idx]] = fields[idx+1]
+	}
+	return paths
+}
+
+func renderFileDiff(fromPath, toPath string) ([]byte, DiffStat, error) {
+	//paths in headers show where resource files come from
+	return renderLabeledFileDiff(fromPath, toPath, TranslateIfResourcePath(fromPath), TranslateIfResourcePath(toPath))
+}
+
+// renderLabeledFileDiff is like renderFileDiff, but shows the given labels
+// instead of the paths in the diff headers.
+func renderLabeledFileDiff(fromPath, toPath, fromPathToDisplay, toPathToDisplay string) ([]byte, DiffStat, error) {
 	fromPathToUse, err := checkFile(fromPath)
 	if err != nil {
-		return nil, err
+		return nil, DiffStat{}, err
 	}
 	toPathToUse, err := checkFile(toPath)
 	if err != nil {
-		return nil, err
+		return nil, DiffStat{}, err
 	}
 
-	//run git-diff to obtain the diff
-	var buffer bytes.Buffer
-	cmd := exec.Command("git", "diff", "--no-index", "--", fromPathToUse, toPathToUse)
-	cmd.Stdout = &buffer
-	cmd.Stderr = Stderr
-
-	//error "exit code 1" is normal for different files, only exit code > 2 means trouble
-	err = cmd.Run()
+	from, err := readDiffSide(fromPathToUse, fromPathToDisplay)
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
-				if status.ExitStatus() == 1 {
-					err = nil
-				}
-			}
-		}
+		return nil, DiffStat{}, err
 	}
-	//did a relevant error occur?
+	to, err := readDiffSide(toPathToUse, toPathToDisplay)
 	if err != nil {
-		return nil, err
+		return nil, DiffStat{}, err
 	}
 
-	//remove "index <SHA1>..<SHA1> <mode>" lines
+	var buffer bytes.Buffer
+	header := fmt.Sprintf("diff --holo %s %s\n", fromPathToDisplay, toPathToDisplay)
+	stat := writeFileDiff(&buffer, header, from, to, diffContextLines)
 	result := buffer.Bytes()
-	rx := regexp.MustCompile(`(?m:^index .*$)\n`)
-	result = rx.ReplaceAll(result, nil)
-
-	//fix paths in headers, especially remove the unnecessary "a/" and "b/"
-	//path prefixes
-	fromPathToDisplay := TranslateIfResourcePath(fromPath)
-	toPathToDisplay := TranslateIfResourcePath(toPath)
-	rx = regexp.MustCompile(`(?m:^diff --git .*$)`)
-	result = rx.ReplaceAll(result, []byte(fmt.Sprintf("diff --holo %s %s", fromPathToDisplay, toPathToDisplay)))
-	rx = regexp.MustCompile(`(?m:^--- a/.*$)`)
-	result = rx.ReplaceAll(result, []byte("--- "+fromPathToDisplay))
-	rx = regexp.MustCompile(`(?m:^\+\+\+ b/.*$)`)
-	result = rx.ReplaceAll(result, []byte("+++ "+toPathToDisplay))
+	if !UseColors(Stdout) {
+		return result, stat, nil
+	}
 
 	//colorize diff
 	rules := []LineColorizingRule{
@@ -250,7 +540,7 @@ func renderFileDiff(fromPath, toPath string) ([]byte, error) {
 		{[]byte("+"), []byte("\x1B[32m")},
 	}
 
-	return ColorizeLines(result, rules), nil
+	return ColorizeLines(result, rules), stat, nil
 }
 
 func checkFile(path string) (pathToUse string, returnError error) {
@@ -258,8 +548,8 @@ func checkFile(path string) (pathToUse string, returnError error) {
 		return path, nil
 	}
 
-	//check that files are either non-existent (in which case git-diff needs to
-	//be given /dev/null instead or manageable (e.g. we can't diff directories
+	//check that files are either non-existent (in which case the diff treats
+	//them like /dev/null) or manageable (e.g. we can't diff directories
 	//or device files)
 	info, err := os.Lstat(path)
 	if err != nil {
@@ -0,0 +1,114 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ExplainStep is one step in the computation of the desired state of an
+// entity, as reported by the "explain" operation.
+type ExplainStep struct {
+	Strategy string
+	Source   string
+	//path to the result of this step, or "" if the step was skipped
+	Result string
+}
+
+// parseExplainMessage parses the message of the "explain" operation: for each
+// step, the strategy, the source and the path to the result of the step.
+func parseExplainMessage(cmdText string) ([]ExplainStep, error) {
+	fields := strings.Split(cmdText, "\000")
+	if len(fields)%3 != 1 || fields[len(fields)-1] != "" {
+		return nil, fmt.Errorf("unexpected response to \"explain\" operation: %q", cmdText)
+	}
+	var steps []ExplainStep
+	for idx := 0; idx+2 < len(fields); idx += 3 {
+		steps = append(steps, ExplainStep{Strategy: fields[idx], Source: fields[idx+1], Result: fields[idx+2]})
+	}
+	if len(steps) == 0 || steps[0].Result == "" {
+		return nil, fmt.Errorf("unexpected response to \"explain\" operation: %q", cmdText)
+	}
+	return steps, nil
+}
+
+// Explain asks the plugin how the desired state of this entity is computed,
+// and prints each step together with a diff of its result against the result
+// of the previous step.
+//
+// The caller must ensure that the plugin supports the "explain" operation.
+func (e *Entity) Explain() error {
+	cmdText, err := e.plugin.RunCommandWithFD3([]string{"explain", e.id}, Stdout, Stderr)
+	if err != nil {
+		return err
+	}
+	steps, err := parseExplainMessage(cmdText)
+	if err != nil {
+		return err
+	}
+
+	if UseColors(Stdout) {
+		fmt.Fprintf(Stdout, "\x1b[1m%s\x1b[0m\n", e.id)
+	} else {
+		fmt.Fprintln(Stdout, e.id)
+	}
+
+	indent := []byte("        ")
+	var previous ExplainStep
+	var previousLabel string
+	for idx, step := range steps {
+		//the label is used in the diff headers
+		label := fmt.Sprintf("step-%d", idx)
+		source := TranslateIfResourcePath(step.Source)
+		if idx == 0 {
+			label = step.Strategy
+			fmt.Fprintf(Stdout, "    %-8s %s\n", step.Strategy, source)
+		} else {
+			fmt.Fprintf(Stdout, "    step %-3d %s %s\n", idx, step.Strategy, source)
+		}
+
+		switch {
+		case idx == 0:
+			//the first step has nothing to compare against
+		case step.Result == "":
+			fmt.Fprintf(Stdout, "%sskipped (the result would be discarded by a later step)\n", indent)
+		default:
+			diff, _, err := renderLabeledFileDiff(previous.Result, step.Result, previousLabel, label)
+			if err != nil {
+				return err
+			}
+			if len(diff) == 0 {
+				fmt.Fprintf(Stdout, "%sno changes\n", indent)
+				break
+			}
+			diff = regexp.MustCompile("(?m:^)").ReplaceAll(diff, indent)
+			Stdout.Write(bytes.TrimSuffix(diff, indent))
+		}
+
+		if step.Result != "" {
+			previous, previousLabel = step, label
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseExplainMessage(t *testing.T) {
+	steps, err := parseExplainMessage("base\000/var/lib/holo/files/base/etc/foo.conf\000/tmp/0/etc/foo.conf\000" +
+		"apply\000/usr/share/holo/files/01-foo/etc/foo.conf\000\000" +
+		"passthru\000/usr/share/holo/files/02-bar/etc/foo.conf.holoscript\000/tmp/2/etc/foo.conf\000")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	expected := []ExplainStep{
+		{"base", "/var/lib/holo/files/base/etc/foo.conf", "/tmp/0/etc/foo.conf"},
+		{"apply", "/usr/share/holo/files/01-foo/etc/foo.conf", ""},
+		{"passthru", "/usr/share/holo/files/02-bar/etc/foo.conf.holoscript", "/tmp/2/etc/foo.conf"},
+	}
+	if !reflect.DeepEqual(steps, expected) {
+		t.Errorf("expected %#v, got %#v", expected, steps)
+	}
+
+	for _, msg := range []string{
+		"",
+		"base\000/etc/foo.conf\000",
+		"base\000/etc/foo.conf\000/tmp/0/etc/foo.conf",
+		//the first step cannot be skipped
+		"base\000/etc/foo.conf\000\000",
+	} {
+		if _, err := parseExplainMessage(msg); err == nil {
+			t.Errorf("expected error for message %q, got none", msg)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import (
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/holocm/holo/internal/osrelease"
+)
+
+// Facts describes the system that Holo is running on, as well as the variables
+// declared with "set NAME=value" in holorc. They are exported to generators and
+// plugins (and thus also to holoscripts, which inherit the environment of
+// holo-files) as environment variables and in a JSON file.
+type Facts struct {
+	Facts     map[string]string `json:"facts"`
+	Variables map[string]string `json:"variables"`
+}
+
+// The facts exported to generators and plugins. This is nil until InitFacts()
+// has been called.
+var currentFacts *Facts
+
+// CollectFacts collects the facts about the system that Holo is running on.
+// Facts that cannot be determined are omitted.
+func CollectFacts(variables map[string]string) *Facts {
+	facts := make(map[string]string)
+
+	//when provisioning an alternate root, the hostname of the running system
+	//is irrelevant; use the hostname configured in the alternate root instead
+	if hostname := readHostname(); hostname != "" {
+		facts["hostname"] = hostname
+	}
+
+	//use the same os-release(5) parsing as holo-files does for choosing its
+	//platform integration
+	if variables, err := osrelease.Read(RootDirectory()); err == nil {
+		for key, name := range map[string]string{"ID": "os_id", "ID_LIKE": "os_id_like", "VERSION_ID": "os_version_id"} {
+			if value := variables[key]; value != "" {
+				facts[name] = value
+			}
+		}
+	}
+
+	var uname syscall.Utsname
+	if err := syscall.Uname(&uname); err == nil {
+		var machine []byte
+		for _, c := range uname.Machine {
+			if c == 0 {
+				break
+			}
+			machine = append(machine, byte(c))
+		}
+		facts["arch"] = string(machine)
+	}
+
+	var sysinfo syscall.Sysinfo_t
+	if err := syscall.Sysinfo(&sysinfo); err == nil {
+		facts["memory"] = strconv.FormatUint(uint64(sysinfo.Totalram)*uint64(sysinfo.Unit), 10)
+	}
+
+	if ifaces, err := net.Interfaces(); err == nil {
+		names := make([]string, 0, len(ifaces))
+		for _, iface := range ifaces {
+			names = append(names, iface.Name)
+		}
+		sort.Strings(names)
+		facts["interfaces"] = strings.Join(names, " ")
+	}
+
+	if variables == nil {
+		variables = make(map[string]string)
+	}
+	return &Facts{Facts: facts, Variables: variables}
+}
+
+// InitFacts collects the facts and writes them into FactsPath(), from where
+// generators and plugins can read them. Must be called inside a
+// WithCacheDirectory() call.
+func InitFacts(config *Configuration) error {
+	facts := CollectFacts(config.Variables)
+	buf, err := json.MarshalIndent(facts, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(FactsPath(), append(buf, '\n'), 0644)
+	if err != nil {
+		return err
+	}
+	currentFacts = facts
+	return nil
+}
+
+// CurrentFacts returns the facts collected by InitFacts().
+func CurrentFacts() *Facts {
+	return currentFacts
+}
+
+// FactsPath returns the path to the JSON file containing the facts.
+func FactsPath() string {
+	return filepath.Join(CachePath(), "facts.json")
+}
+
+// Environ returns the facts and variables as environment variables of the form
+// "HOLO_FACT_$NAME=$VALUE" and "HOLO_VAR_$NAME=$VALUE".
+func (f *Facts) Environ() []string {
+	var env []string
+	for _, name := range sortedKeys(f.Facts) {
+		env = append(env, "HOLO_FACT_"+strings.ToUpper(name)+"="+f.Facts[name])
+	}
+	for _, name := range sortedKeys(f.Variables) {
+		env = append(env, "HOLO_VAR_"+name+"="+f.Variables[name])
+	}
+	return env
+}
+
+// factsEnviron returns the environment variables that export the facts to
+// generators and plugins.
+func factsEnviron() []string {
+	if currentFacts == nil {
+		return nil
+	}
+	return append(currentFacts.Environ(), "HOLO_FACTS_FILE="+FactsPath())
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func readHostname() string {
+	if RootDirectory() != "/" {
+		buf, err := os.ReadFile(filepath.Join(RootDirectory(), "etc/hostname"))
+		if err == nil {
+			return strings.TrimSpace(string(buf))
+		}
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+	return hostname
+}
@@ -0,0 +1,76 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFactsEnviron(t *testing.T) {
+	facts := &Facts{
+		Facts:     map[string]string{"os_id": "arch", "hostname": "web1"},
+		Variables: map[string]string{"ROLE": "webserver", "region": "eu"},
+	}
+	expected := []string{
+		"HOLO_FACT_HOSTNAME=web1",
+		"HOLO_FACT_OS_ID=arch",
+		"HOLO_VAR_ROLE=webserver",
+		"HOLO_VAR_region=eu",
+	}
+	if actual := facts.Environ(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %#v, got %#v", expected, actual)
+	}
+}
+
+func TestCollectFacts(t *testing.T) {
+	defer func(dir string) { rootDirectory = dir }(rootDirectory)
+	rootDirectory = t.TempDir()
+
+	err := os.MkdirAll(filepath.Join(rootDirectory, "usr/lib"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(rootDirectory, "usr/lib/os-release"), []byte("ID=debian\nVERSION_ID=\"12\"\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	facts := CollectFacts(map[string]string{"ROLE": "webserver"})
+	for name, value := range map[string]string{"os_id": "debian", "os_version_id": "12"} {
+		if facts.Facts[name] != value {
+			t.Errorf("expected fact %s = %q, got %q", name, value, facts.Facts[name])
+		}
+	}
+	if _, exists := facts.Facts["os_id_like"]; exists {
+		t.Errorf("expected no fact os_id_like, got %q", facts.Facts["os_id_like"])
+	}
+	for _, name := range []string{"arch", "memory"} {
+		if facts.Facts[name] == "" {
+			t.Errorf("expected fact %s to be set", name)
+		}
+	}
+	if facts.Variables["ROLE"] != "webserver" {
+		t.Errorf("expected variable ROLE = webserver, got %q", facts.Variables["ROLE"])
+	}
+}
@@ -22,6 +22,7 @@
 package impl
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
@@ -31,6 +32,7 @@ import (
 	"strings"
 
 	"github.com/holocm/holo/internal/fs"
+	"github.com/holocm/holo/internal/procgroup"
 )
 
 // Tracks which resource file was generated by which generator.
@@ -47,6 +49,9 @@ func RunAllGenerators() error {
 		//non-executable file in the generators directory just produces an obvious
 		//error during exec.Command() down below.
 		if info.Mode().IsRegular() {
+			if Interrupted() {
+				return fmt.Errorf("interrupted before running %s", path)
+			}
 			return runGenerator(path, filepathMustRel(generatorsDir, path))
 		}
 		return nil
@@ -57,7 +62,13 @@ func runGenerator(generatorPath, generatorRelPath string) error {
 	//prepare a cache directory with a unique name for the generator
 	generatorID := sha256.Sum256([]byte(generatorPath))
 	cacheDir := filepath.Join(CachePath(), hex.EncodeToString(generatorID[:]))
-	err := os.Mkdir(cacheDir, 0777)
+	//(when the virtual resource root is rebuilt, the cache directory from the
+	//previous run of this generator needs to be cleaned up first)
+	err := os.RemoveAll(cacheDir)
+	if err != nil {
+		return err
+	}
+	err = os.Mkdir(cacheDir, 0777)
 	if err != nil {
 		return err
 	}
@@ -69,15 +80,24 @@ func runGenerator(generatorPath, generatorRelPath string) error {
 		"HOLO_RESOURCE_ROOT="+filepath.Join(RootDirectory(), "/usr/share/holo"),
 		"OUT="+VirtualResourceRoot(),
 	)
-
-	//run the generator
-	out, err := cmd.CombinedOutput()
-	for _, line := range strings.Split(string(out), "\n") {
+	cmd.Env = append(cmd.Env, factsEnviron()...)
+
+	//run the generator (in its own process group, see HandleInterrupts)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	ctx, cancel := operationContext("generator")
+	defer cancel()
+	err = procgroup.Run(ctx, cmd)
+	for _, line := range strings.Split(out.String(), "\n") {
 		line = strings.TrimSpace(line)
 		if line != "" {
 			Warnf(Stderr, "output from %s: %s", generatorPath, line)
 		}
 	}
+	if err != nil && ctx.Err() != nil {
+		err = operationError(ctx, "generator")
+	}
 	if err != nil {
 		return fmt.Errorf("could not run %s: %w", generatorPath, err)
 	}
@@ -103,6 +123,18 @@ func runGenerator(generatorPath, generatorRelPath string) error {
 	})
 }
 
+// ResetVirtualResourceRoot removes all resource files from the
+// VirtualResourceRoot(), so that it can be filled again by RunAllGenerators()
+// and FinalizeVirtualResourceRoot().
+func ResetVirtualResourceRoot() error {
+	err := os.RemoveAll(VirtualResourceRoot())
+	if err != nil {
+		return err
+	}
+	generatorForResourceFile = map[string]string{}
+	return os.Mkdir(VirtualResourceRoot(), 0777)
+}
+
 // FinalizeVirtualResourceRoot copies into VirtualResourceRoot() all static
 // resource files not overwritten by a generated resource file.
 func FinalizeVirtualResourceRoot() error {
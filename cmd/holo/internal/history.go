@@ -0,0 +1,187 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// HistoryEntry records a single run of `holo apply` or `holo rollback` in the
+// history file.
+type HistoryEntry struct {
+	Time time.Time `json:"time"`
+	//the command-line arguments (without the program name)
+	Command []string `json:"command"`
+	//the ID of the journal that was recorded by `holo apply` (or restored by
+	//`holo rollback`), if any
+	Journal  string          `json:"journal,omitempty"`
+	Entities []HistoryEntity `json:"entities"`
+}
+
+// HistoryEntity records what happened to a single entity in a HistoryEntry.
+type HistoryEntity struct {
+	ID           string `json:"id"`
+	PluginID     string `json:"plugin"`
+	ActionVerb   string `json:"action_verb,omitempty"`
+	ActionReason string `json:"action_reason,omitempty"`
+	Outcome      string `json:"outcome"`
+	Error        string `json:"error,omitempty"`
+	//hashes of the actual state of the entity before and after it was
+	//changed (only for changed entities, as reported by the plugin with
+	//pluginapi.ReportStateHashes())
+	StateBefore string `json:"state_before,omitempty"`
+	StateAfter  string `json:"state_after,omitempty"`
+}
+
+// currentHistoryEntry is the entry for the current run, or nil if the current
+// operation is not recorded in the history.
+var currentHistoryEntry *HistoryEntry
+
+// HistoryPath returns the path to the history file.
+func HistoryPath() string {
+	return filepath.Join(RootDirectory(), "var/lib/holo/history")
+}
+
+// StartHistory starts recording the current run for the history. The command
+// is given as the list of command-line arguments without the program name.
+func StartHistory(command []string) {
+	currentHistoryEntry = &HistoryEntry{
+		Time:    time.Now().UTC().Truncate(time.Second),
+		Command: command,
+	}
+}
+
+// FinishHistory appends the entry for the current run to the history file,
+// with the outcomes of the given entities. Entities that were not applied (or
+// rolled back or skipped) are not recorded.
+func FinishHistory(entities []*Entity) error {
+	entry := currentHistoryEntry
+	currentHistoryEntry = nil
+	if entry == nil {
+		return nil
+	}
+	entry.Journal = currentJournalRunID()
+
+	for _, e := range entities {
+		outcome, ok := e.Outcome()
+		if !ok {
+			continue
+		}
+		he := HistoryEntity{
+			ID:           e.id,
+			PluginID:     e.plugin.id,
+			ActionVerb:   e.actionVerb,
+			ActionReason: e.actionReason,
+			Outcome:      outcome.String(),
+			StateBefore:  e.stateBefore,
+			StateAfter:   e.stateAfter,
+		}
+		//like in EntityReport, the default action verb is omitted
+		if he.ActionVerb == "Working on" && he.ActionReason == "" {
+			he.ActionVerb = ""
+		}
+		if e.outcomeError != nil {
+			he.Error = e.outcomeError.Error()
+		}
+		entry.Entities = append(entry.Entities, he)
+	}
+	if len(entry.Entities) == 0 {
+		return nil
+	}
+
+	buf, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(HistoryPath()), 0755)
+	if err != nil {
+		return err
+	}
+	//the hashes may reveal information about the contents of protected files
+	file, err := os.OpenFile(HistoryPath(), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(buf, '\n'))
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// ReadHistory returns all entries in the history file, from oldest to newest.
+func ReadHistory() ([]HistoryEntry, error) {
+	file, err := os.Open(HistoryPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 16<<20)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		var entry HistoryEntry
+		err := json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			return nil, fmt.Errorf("malformed line %d in %s: %s", lineNo, file.Name(), err.Error())
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// HistoryEntities returns the entities that appear in the history (for
+// matching them against the selectors given to `holo log`). Entities of
+// plugins that are not configured anymore are included as well.
+func HistoryEntities(entries []HistoryEntry, plugins []*Plugin) []*Entity {
+	pluginByID := make(map[string]*Plugin, len(plugins))
+	for _, plugin := range plugins {
+		pluginByID[plugin.id] = plugin
+	}
+
+	var result []*Entity
+	isSeen := make(map[string]bool)
+	for _, entry := range entries {
+		for _, he := range entry.Entities {
+			if isSeen[he.ID] {
+				continue
+			}
+			isSeen[he.ID] = true
+			plugin := pluginByID[he.PluginID]
+			if plugin == nil {
+				plugin = &Plugin{id: he.PluginID}
+				pluginByID[he.PluginID] = plugin
+			}
+			result = append(result, &Entity{plugin: plugin, id: he.ID, actionVerb: "Working on"})
+		}
+	}
+	return result
+}
@@ -0,0 +1,123 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	defer func(dir string) { rootDirectory = dir }(rootDirectory)
+	rootDirectory = t.TempDir()
+
+	//without recorded runs, the history is empty
+	entries, err := ReadHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected empty history, got %#v", entries)
+	}
+
+	plugin := &Plugin{id: "files"}
+	changed := &Entity{plugin: plugin, id: "file:/etc/foo.conf", actionVerb: "Working on", stateBefore: "absent", stateAfter: "sha256:1234"}
+	changed.recordOutcome(ApplyChanged, nil)
+	failed := &Entity{plugin: plugin, id: "file:/etc/bar.conf", actionVerb: "Scrubbing", actionReason: "target was deleted"}
+	failed.recordOutcome(ApplyFailed, errors.New("exit status 1"))
+	notApplied := &Entity{plugin: &Plugin{id: "users-groups"}, id: "user:john", actionVerb: "Working on"}
+
+	//a run is only recorded after StartHistory()
+	err = FinishHistory([]*Entity{changed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(HistoryPath()); !os.IsNotExist(err) {
+		t.Errorf("expected no history file, got err = %v", err)
+	}
+
+	for _, command := range [][]string{{"apply"}, {"apply", "--force", "files"}} {
+		StartHistory(command)
+		err = FinishHistory([]*Entity{changed, failed, notApplied})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err = ReadHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries in history, got %d", len(entries))
+	}
+	if !reflect.DeepEqual(entries[1].Command, []string{"apply", "--force", "files"}) {
+		t.Errorf("unexpected command in history: %#v", entries[1].Command)
+	}
+	expected := []HistoryEntity{
+		{ID: "file:/etc/foo.conf", PluginID: "files", Outcome: "changed", StateBefore: "absent", StateAfter: "sha256:1234"},
+		{ID: "file:/etc/bar.conf", PluginID: "files", ActionVerb: "Scrubbing", ActionReason: "target was deleted", Outcome: "failed", Error: "exit status 1"},
+	}
+	if !reflect.DeepEqual(entries[1].Entities, expected) {
+		t.Errorf("expected entities %#v, got %#v", expected, entries[1].Entities)
+	}
+
+	//entities of plugins that are not configured anymore can still be selected
+	entities := HistoryEntities(entries, []*Plugin{plugin})
+	if len(entities) != 2 || entities[0].plugin != plugin || entities[1].PluginID() != "files" {
+		t.Errorf("unexpected entities from history: %#v", entities)
+	}
+}
+
+func TestApplyRecordsStateHashes(t *testing.T) {
+	executablePath := filepath.Join(t.TempDir(), "holo-test")
+	script := "#!/bin/sh\n[ \"$2\" = test:unchanged ] && echo 'not changed' >&3\nprintf 'state before: absent\\nstate after: sha256:1234\\n' >&3\n"
+	err := os.WriteFile(executablePath, []byte(script), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plugin := &Plugin{id: "test", executablePath: executablePath, apiVersion: 3}
+
+	WithCacheDirectory(func() int {
+		changed := &Entity{plugin: plugin, id: "test:changed"}
+		outcome, err := changed.Apply(false)
+		if err != nil || outcome != ApplyChanged {
+			t.Fatalf("unexpected result for %s: %s, %v", changed.id, outcome, err)
+		}
+		if changed.stateBefore != "absent" || changed.stateAfter != "sha256:1234" {
+			t.Errorf("unexpected state hashes for %s: %q -> %q", changed.id, changed.stateBefore, changed.stateAfter)
+		}
+
+		//hashes are only recorded for changed entities
+		unchanged := &Entity{plugin: plugin, id: "test:unchanged"}
+		outcome, err = unchanged.Apply(false)
+		if err != nil || outcome != ApplyUnchanged {
+			t.Fatalf("unexpected result for %s: %s, %v", unchanged.id, outcome, err)
+		}
+		if unchanged.stateBefore != "" || unchanged.stateAfter != "" {
+			t.Errorf("unexpected state hashes for %s: %q -> %q", unchanged.id, unchanged.stateBefore, unchanged.stateAfter)
+		}
+		return 0
+	})
+}
@@ -0,0 +1,96 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+)
+
+var (
+	//set to 1 when the first SIGINT or SIGTERM is received
+	interrupted int32
+	//closed when the first SIGINT or SIGTERM is received
+	interruptChan = make(chan struct{})
+	//cancelled when the second SIGINT or SIGTERM is received
+	abortContext, abort = context.WithCancel(context.Background())
+)
+
+// HandleInterrupts installs a handler for SIGINT and SIGTERM. On the first
+// signal, Interrupted() starts to return true, so that Holo can finish the
+// current entity and skip all remaining ones. On the second signal, all running
+// plugins, generators and holoscripts are killed. The returned function
+// uninstalls the handler.
+//
+// Note that plugins, generators and holoscripts run in their own process
+// group, so Ctrl-C in the terminal only reaches Holo itself.
+func HandleInterrupts() (stop func()) {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-signals:
+				if atomic.CompareAndSwapInt32(&interrupted, 0, 1) {
+					Warnf(os.Stderr, "Interrupted: stopping after the current operation (interrupt again to abort it)")
+					close(interruptChan)
+				} else {
+					abort()
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
+// Interrupted returns whether Holo received SIGINT or SIGTERM.
+func Interrupted() bool {
+	return atomic.LoadInt32(&interrupted) != 0
+}
+
+// Interrupts returns a channel that is closed when Holo receives SIGINT or
+// SIGTERM.
+func Interrupts() <-chan struct{} {
+	return interruptChan
+}
+
+// ReportNotProcessed reports the given entities as not processed because Holo
+// was interrupted.
+func ReportNotProcessed(entities []*Entity) {
+	if len(entities) == 0 {
+		return
+	}
+	Errorf(Stderr, "Interrupted: the following entities were not processed:")
+	for _, entity := range entities {
+		fmt.Fprintf(Stderr, "    %s\n", entity.EntityID())
+	}
+	Stderr.EndParagraph()
+}
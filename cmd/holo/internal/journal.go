@@ -0,0 +1,248 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/holocm/holo/internal/pluginapi"
+)
+
+// journalRetention is the number of runs of `holo apply` for which journals
+// are kept.
+const journalRetention = 10
+
+// runIDFormat is the format of run IDs (which are the names of the journal
+// directories), as accepted by time.Format().
+const runIDFormat = "20060102-150405"
+
+// journalRunDir is the journal directory of the current run of `holo apply`,
+// or of the run that is rolled back by `holo rollback`. It is empty if there
+// is no journal for the current operation.
+var journalRunDir string
+
+// JournalRootDirectory returns the directory containing the journals of
+// previous runs of `holo apply`.
+func JournalRootDirectory() string {
+	return filepath.Join(RootDirectory(), "var/lib/holo/journal")
+}
+
+// JournalDirectory returns the directory where this plugin records the prior
+// states of the entities that it changes, or an empty string if there is no
+// journal for the current operation.
+func (p *Plugin) JournalDirectory() string {
+	if journalRunDir == "" {
+		return ""
+	}
+	return filepath.Join(journalRunDir, p.id)
+}
+
+// ListJournalRuns returns the IDs of all runs for which journals exist, from
+// oldest to newest.
+func ListJournalRuns() ([]string, error) {
+	dir, err := os.Open(JournalRootDirectory())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	names, err := dir.Readdirnames(-1)
+	dir.Close()
+	if err != nil {
+		return nil, err
+	}
+	//run IDs sort chronologically (see createJournalRunDir())
+	sort.Strings(names)
+	return names, nil
+}
+
+// StartJournal creates the journal directory for a new run of `holo apply`.
+// Plugins will record the prior states of the entities that they change in
+// there.
+func StartJournal() error {
+	err := os.MkdirAll(JournalRootDirectory(), 0755)
+	if err != nil {
+		return err
+	}
+
+	path, err := createJournalRunDir(time.Now().UTC().Format(runIDFormat))
+	if err != nil {
+		return err
+	}
+	journalRunDir = path
+	return nil
+}
+
+// createJournalRunDir creates the directory for a new run in the journal root
+// directory. The given timestamp is used as run ID, with a suffix if there was
+// another run in the same second. The suffix is zero-padded, such that
+// ListJournalRuns() lists runs in order when sorting run IDs as strings, e.g.
+//
+//	20260101-120000 < 20260101-120000-002 < 20260101-120000-010 < 20260101-120001
+func createJournalRunDir(timestamp string) (string, error) {
+	runID := timestamp
+	for idx := 2; ; idx++ {
+		path := filepath.Join(JournalRootDirectory(), runID)
+		err := os.Mkdir(path, 0700)
+		if err == nil {
+			return path, nil
+		}
+		if !os.IsExist(err) {
+			return "", err
+		}
+		runID = fmt.Sprintf("%s-%03d", timestamp, idx)
+	}
+}
+
+// RecordInJournal adds the given entity to the journal of the current run if
+// its plugin has recorded its prior state (which plugins only do when they
+// change the entity).
+func RecordInJournal(e *Entity) error {
+	if journalRunDir == "" {
+		return nil
+	}
+	_, err := os.Lstat(pluginapi.JournalEntryPath(e.plugin.JournalDirectory(), e.id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	file, err := os.OpenFile(filepath.Join(journalRunDir, "entities"), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(file, "%s %s\n", e.plugin.id, e.id)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// currentJournalRunID returns the run ID of the journal of the current
+// operation, or an empty string if there is none (or if nothing was recorded
+// in it).
+func currentJournalRunID() string {
+	if journalRunDir == "" {
+		return ""
+	}
+	_, err := os.Stat(filepath.Join(journalRunDir, "entities"))
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(journalRunDir)
+}
+
+// FinishJournal is called at the end of `holo apply`. If no entities were
+// recorded in the journal of the current run, it is removed. Journals of old
+// runs are removed as well, such that only the most recent ones remain.
+func FinishJournal() {
+	if journalRunDir == "" {
+		return
+	}
+	_, err := os.Stat(filepath.Join(journalRunDir, "entities"))
+	if os.IsNotExist(err) {
+		err = os.RemoveAll(journalRunDir)
+	}
+	if err != nil {
+		Errorf(Stderr, err.Error())
+	}
+	journalRunDir = ""
+
+	runIDs, err := ListJournalRuns()
+	if err != nil {
+		Errorf(Stderr, err.Error())
+		return
+	}
+	for len(runIDs) > journalRetention {
+		err := os.RemoveAll(filepath.Join(JournalRootDirectory(), runIDs[0]))
+		if err != nil {
+			Errorf(Stderr, err.Error())
+		}
+		runIDs = runIDs[1:]
+	}
+}
+
+// ErrNoJournal is returned by OpenJournal when there is no journal to roll back.
+var ErrNoJournal = errors.New("no journal found (`holo apply` has not changed any entities yet)")
+
+// OpenJournal selects the journal of a previous run of `holo apply` (for `holo
+// rollback`), and returns the entities that were changed in this run, in the
+// order in which they were applied. If runID is empty, the most recent run is
+// selected.
+func OpenJournal(runID string, plugins []*Plugin) ([]*Entity, error) {
+	if runID == "" {
+		runIDs, err := ListJournalRuns()
+		if err != nil {
+			return nil, err
+		}
+		if len(runIDs) == 0 {
+			return nil, ErrNoJournal
+		}
+		runID = runIDs[len(runIDs)-1]
+	}
+	journalRunDir = filepath.Join(JournalRootDirectory(), runID)
+
+	file, err := os.Open(filepath.Join(journalRunDir, "entities"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	pluginByID := make(map[string]*Plugin, len(plugins))
+	for _, plugin := range plugins {
+		pluginByID[plugin.id] = plugin
+	}
+
+	var entities []*Entity
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), " ", 2)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("malformed line in %s: %q", file.Name(), scanner.Text())
+		}
+		plugin := pluginByID[fields[0]]
+		if plugin == nil {
+			return nil, fmt.Errorf("cannot roll back %s: plugin %s is not configured", fields[1], fields[0])
+		}
+		entities = append(entities, &Entity{plugin: plugin, id: fields[1], actionVerb: "Rolling back"})
+	}
+	return entities, scanner.Err()
+}
+
+// IsJournalRun returns whether a journal exists for a run with the given ID.
+func IsJournalRun(runID string) bool {
+	if runID == "" || strings.HasPrefix(runID, ".") || strings.ContainsRune(runID, '/') {
+		return false
+	}
+	fi, err := os.Stat(filepath.Join(JournalRootDirectory(), runID))
+	return err == nil && fi.IsDir()
+}
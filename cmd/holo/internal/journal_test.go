@@ -0,0 +1,123 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestJournalRetention(t *testing.T) {
+	defer func(dir string) { rootDirectory = dir }(rootDirectory)
+	rootDirectory = t.TempDir()
+
+	//prepare journals of previous runs
+	var runIDs []string
+	for idx := 0; idx < journalRetention+2; idx++ {
+		runID := fmt.Sprintf("20250101-0000%02d", idx)
+		runIDs = append(runIDs, runID)
+		err := os.MkdirAll(filepath.Join(JournalRootDirectory(), runID), 0700)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := StartJournal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	runID := filepath.Base(journalRunDir)
+	if !IsJournalRun(runID) {
+		t.Errorf("expected journal for run %s to exist", runID)
+	}
+
+	//since no entities were recorded, the new journal is removed again, and
+	//the oldest journals are pruned
+	FinishJournal()
+	actual, err := ListJournalRuns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := runIDs[2:]
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected journal runs %v, got %v", expected, actual)
+	}
+}
+
+func TestJournalRunIDs(t *testing.T) {
+	defer func(dir string) { rootDirectory = dir }(rootDirectory)
+	rootDirectory = t.TempDir()
+	err := os.MkdirAll(JournalRootDirectory(), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//runs in the same second get a suffix, and are still listed in the order
+	//in which they were started
+	var expected []string
+	for _, timestamp := range []string{"20250101-000000", "20250101-000000", "20250101-000001"} {
+		for idx := 0; idx < 6; idx++ {
+			path, err := createJournalRunDir(timestamp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected = append(expected, filepath.Base(path))
+		}
+	}
+	if expected[1] != "20250101-000000-002" || expected[11] != "20250101-000000-012" || expected[12] != "20250101-000001" {
+		t.Errorf("unexpected run IDs: %v", expected)
+	}
+	actual, err := ListJournalRuns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected journal runs %v, got %v", expected, actual)
+	}
+}
+
+func TestIsJournalRun(t *testing.T) {
+	defer func(dir string) { rootDirectory = dir }(rootDirectory)
+	rootDirectory = t.TempDir()
+
+	err := os.MkdirAll(filepath.Join(JournalRootDirectory(), "20250101-000000"), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := map[string]bool{
+		"20250101-000000": true,
+		"20250101-000001": false,
+		"":                false,
+		".":               false,
+		"..":              false,
+		"../journal":      false,
+		"file:/etc/foo":   false,
+	}
+	for runID, expected := range testcases {
+		if actual := IsJournalRun(runID); actual != expected {
+			t.Errorf("IsJournalRun(%q): expected %v, got %v", runID, expected, actual)
+		}
+	}
+}
@@ -22,48 +22,206 @@ package impl
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 )
 
+// LockMode is the type of lock taken by AcquireLockfile.
+type LockMode int
+
+const (
+	//SharedLock is taken by read-only operations. Multiple instances of Holo
+	//can hold a shared lock at the same time.
+	SharedLock LockMode = iota
+	//ExclusiveLock is taken by operations that modify the system.
+	ExclusiveLock
+)
+
+// WaitForever can be given as timeout to AcquireLockfile().
+const WaitForever time.Duration = -1
+
 var (
 	lockPath string
 	lockFile *os.File
+	lockMode LockMode
 )
 
-// AcquireLockfile will create a lock file to ensure that only one instance of
-// Holo is running at the same time. Returns whether the operation succeeded.
-func AcquireLockfile() bool {
+// AcquireLockfile locks the lock file to ensure that only one instance of Holo
+// modifies the system at the same time. The lock is tied to the open file
+// descriptor, so the kernel releases it automatically when Holo dies.
+//
+// If another instance of Holo holds a conflicting lock, wait for up to the
+// given timeout (or indefinitely for WaitForever) until the lock is released.
+// Returns whether the operation succeeded.
+func AcquireLockfile(mode LockMode, timeout time.Duration) bool {
 	lockPath = filepath.Join(RootDirectory(), "run/holo.pid")
+	lockMode = mode
+	how := syscall.LOCK_SH
+	if mode == ExclusiveLock {
+		how = syscall.LOCK_EX
+	}
 
-	var err error
-	lockFile, err = os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
-	if err != nil {
-		Errorf(Stderr, "Cannot create lock file %s: %s", lockPath, err.Error())
-		//is this the "file exists" error that indicates another running instance?
-		suberr := err.(*os.PathError).Err
-		if errno, ok := suberr.(syscall.Errno); ok {
-			if errno == syscall.EEXIST {
-				fmt.Fprintln(Stderr, "This usually means that another instance of Holo is currently running.")
-				fmt.Fprintln(Stderr, "If not, you can try to delete the lock file manually.")
+	startTime := time.Now()
+	isWaiting := false
+	for {
+		file, err := openLockfile(mode)
+		if err != nil {
+			Errorf(Stderr, "Cannot open lock file %s: %s", lockPath, err.Error())
+			return false
+		}
+		if file == nil {
+			//read-only operations may proceed without lock when they are not
+			//allowed to create the lock file (e.g. for non-root users)
+			return true
+		}
+
+		err = syscall.Flock(int(file.Fd()), how|syscall.LOCK_NB)
+		if err == nil {
+			if isLockfileCurrent(file) {
+				lockFile = file
+				break
 			}
+			//the previous owner removed the lock file while we were waiting
+			//for it, so try again with the new lock file
+			file.Close()
+			continue
+		}
+		pid := readPIDFromLockfile(file)
+		file.Close()
+		if err != syscall.EWOULDBLOCK {
+			Errorf(Stderr, "Cannot lock %s: %s", lockPath, err.Error())
+			return false
+		}
+
+		//another instance of Holo is running - wait or give up
+		owner := "another instance of Holo"
+		if pid > 0 {
+			owner += fmt.Sprintf(" (PID %d)", pid)
+		}
+		if timeout != WaitForever && time.Since(startTime) >= timeout {
+			if isWaiting {
+				Errorf(Stderr, "Cannot lock %s: timed out after %s while waiting for %s", lockPath, timeout, owner)
+			} else {
+				Errorf(Stderr, "Cannot lock %s: %s is currently running", lockPath, owner)
+				fmt.Fprintln(Stderr, "Use --wait to wait until it has finished.")
+			}
+			return false
+		}
+		if Interrupted() {
+			Errorf(Stderr, "Cannot lock %s: interrupted while waiting for %s", lockPath, owner)
+			return false
+		}
+		if !isWaiting {
+			fmt.Fprintf(Stderr, "Waiting for %s to finish...\n", owner)
+			isWaiting = true
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	//the lock file should be empty unless the previous owner did not get to
+	//clean it up
+	if pid := readPIDFromLockfile(lockFile); pid > 0 {
+		if isProcessRunning(pid) {
+			Warnf(Stderr, "Ignoring stale lock file %s (left behind by PID %d, which does not hold the lock)", lockPath, pid)
+		} else {
+			Warnf(Stderr, "Ignoring stale lock file %s (left behind by PID %d, which is not running anymore)", lockPath, pid)
+		}
+	}
+
+	if mode == ExclusiveLock {
+		err := lockFile.Truncate(0)
+		if err == nil {
+			_, err = lockFile.WriteAt([]byte(fmt.Sprintf("%d\n", os.Getpid())), 0)
+		}
+		if err == nil {
+			err = lockFile.Sync()
+		}
+		if err != nil {
+			Errorf(Stderr, "Cannot write lock file %s: %s", lockPath, err.Error())
+			ReleaseLockfile()
+			return false
 		}
-		return false
 	}
-	fmt.Fprintf(lockFile, "%d\n", os.Getpid())
-	lockFile.Sync()
 	return true
 }
 
-// ReleaseLockfile removes the lock file created by AcquireLockfile.
+// ReleaseLockfile removes the lock file and releases the lock acquired by
+// AcquireLockfile.
 func ReleaseLockfile() {
-	err := lockFile.Close()
+	if lockFile == nil {
+		return
+	}
+
+	//the lock file may only be removed while no one else holds a lock on it
+	//(when this is a shared lock, other instances may still be reading)
+	err := syscall.Flock(int(lockFile.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err == nil {
+		err = os.Remove(lockPath)
+		//for read-only operations, it's not a problem if the lock file
+		//remains (e.g. for non-root users who may not remove it)
+		if err != nil && lockMode == ExclusiveLock {
+			Errorf(Stderr, err.Error())
+		}
+	}
+
+	err = lockFile.Close()
 	if err != nil {
 		Errorf(Stderr, err.Error())
 	}
-	err = os.Remove(lockPath)
+	lockFile = nil
+}
+
+// openLockfile opens (and if necessary, creates) the lock file. For shared
+// locks, nil is returned if the lock file cannot be opened because of
+// insufficient permissions.
+func openLockfile(mode LockMode) (*os.File, error) {
+	file, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0644)
+	if err == nil || mode == ExclusiveLock {
+		return file, err
+	}
+
+	//for shared locks, a read-only file descriptor is sufficient
+	file, err = os.Open(lockPath)
+	if os.IsNotExist(err) || os.IsPermission(err) {
+		return nil, nil
+	}
+	return file, err
+}
+
+// isLockfileCurrent checks whether the given file is still the lock file, or
+// whether it has been removed by ReleaseLockfile() in another instance of Holo.
+func isLockfileCurrent(file *os.File) bool {
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	pathInfo, err := os.Stat(lockPath)
 	if err != nil {
-		Errorf(Stderr, err.Error())
+		return false
+	}
+	return os.SameFile(fileInfo, pathInfo)
+}
+
+// readPIDFromLockfile returns the PID written into the lock file by the
+// instance of Holo holding an exclusive lock, or 0 if there is none.
+func readPIDFromLockfile(file *os.File) int {
+	buf, err := io.ReadAll(io.NewSectionReader(file, 0, 64))
+	if err != nil {
+		return 0
 	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(buf)))
+	if err != nil || pid <= 0 {
+		return 0
+	}
+	return pid
+}
+
+func isProcessRunning(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
 }
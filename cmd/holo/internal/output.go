@@ -33,7 +33,7 @@ func Errorf(writer io.Writer, text string, args ...interface{}) {
 	if len(args) > 0 {
 		text = fmt.Sprintf(text, args...)
 	}
-	fmt.Fprintf(writer, "\x1b[1;31m!! %s\x1b[0m\n", strings.TrimSuffix(text, "\n"))
+	printMessage(writer, "!! ", "\x1b[1;31m", text)
 }
 
 // Warnf formats and prints an warning message on stderr.
@@ -41,7 +41,15 @@ func Warnf(writer io.Writer, text string, args ...interface{}) {
 	if len(args) > 0 {
 		text = fmt.Sprintf(text, args...)
 	}
-	fmt.Fprintf(writer, "\x1b[1;33m>> %s\x1b[0m\n", strings.TrimSuffix(text, "\n"))
+	printMessage(writer, ">> ", "\x1b[1;33m", text)
+}
+
+func printMessage(writer io.Writer, prefix, color, text string) {
+	text = prefix + strings.TrimSuffix(text, "\n")
+	if UseColors(writer) {
+		text = color + text + "\x1b[0m"
+	}
+	fmt.Fprintln(writer, text)
 }
 
 // ParagraphTracker is used in conjunction with ParagraphWriter. See explanation
@@ -71,6 +79,14 @@ var Stdout = &ParagraphWriter{Writer: os.Stdout, Tracker: stdTracker}
 // Stderr wraps os.Stderr into a ParagraphWriter.
 var Stderr = &ParagraphWriter{Writer: os.Stderr, Tracker: stdTracker}
 
+// RedirectStdoutToStderr makes Stdout write into os.Stderr instead of
+// os.Stdout. This is used when os.Stdout is reserved for machine-readable
+// output.
+func RedirectStdoutToStderr() {
+	Stdout.Writer = os.Stderr
+	stdTracker.PrimaryWriter = os.Stderr
+}
+
 func (t *ParagraphTracker) observeOutput(p []byte) {
 	//print the initial newline before any other output
 	if !t.hadOutput {
@@ -153,8 +169,12 @@ type LineColorizingRule struct {
 }
 
 // ColorizeLine adds color to the given line according to the first of the given
-// `rules` that matches.
+// `rules` that matches. Lines that already start with an ANSI escape sequence
+// are left alone.
 func ColorizeLine(line []byte, rules []LineColorizingRule) []byte {
+	if len(line) > 0 && line[0] == '\x1b' {
+		return line
+	}
 	for _, rule := range rules {
 		if bytes.HasPrefix(line, rule.Prefix) {
 			return bytes.Join([][]byte{rule.Color, line, []byte("\x1b[0m")}, nil)
@@ -22,6 +22,7 @@ package impl
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -30,10 +31,16 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+
+	"github.com/holocm/holo/internal/procgroup"
 )
 
-// PluginAPIVersion is the version of holo-plugin-interface(7) implemented by this.
-const PluginAPIVersion = 3
+// MinPluginAPIVersion and MaxPluginAPIVersion describe the range of versions
+// of holo-plugin-interface(7) implemented by this.
+const (
+	MinPluginAPIVersion = 3
+	MaxPluginAPIVersion = 4
+)
 
 // ErrPluginExecutableMissing indicates that a plugin's executable file is missing.
 var ErrPluginExecutableMissing = errors.New("ErrPluginExecutableMissing")
@@ -42,21 +49,42 @@ var ErrPluginExecutableMissing = errors.New("ErrPluginExecutableMissing")
 type Plugin struct {
 	id                      string
 	executablePath          string
+	settings                PluginSettings    //from holorc
 	metadata                map[string]string //from "info" call
 	usesVirtualResourceRoot bool              //can only be set once VirtualResourceRoot() is finalized
+	apiVersion              int               //negotiated after "info" call
+	session                 *pluginSession    //only used with API version 4 and above
 }
 
 // NewPlugin creates a new Plugin.
 func NewPlugin(id string) (*Plugin, error) {
-	executablePath := filepath.Join(RootDirectory(), "usr/lib/holo/holo-"+id)
-	return NewPluginWithExecutablePath(id, executablePath)
+	return NewPluginWithExecutablePath(id, DefaultPluginExecutablePath(id))
+}
+
+// DefaultPluginExecutablePath returns the path where the executable of the
+// plugin with the given ID is installed.
+func DefaultPluginExecutablePath(id string) string {
+	return filepath.Join(RootDirectory(), "usr/lib/holo/holo-"+id)
 }
 
 // NewPluginWithExecutablePath creates a new Plugin whose executable resides in
 // a non-standard location. (This is used exclusively for testing plugins before
 // they are installed.)
 func NewPluginWithExecutablePath(id string, executablePath string) (*Plugin, error) {
-	p := &Plugin{id, executablePath, make(map[string]string), false}
+	return NewPluginWithSettings(id, PluginSettings{ExecutablePath: executablePath})
+}
+
+// NewPluginWithSettings creates a new Plugin with the settings given for it in
+// holorc.
+func NewPluginWithSettings(id string, settings PluginSettings) (*Plugin, error) {
+	executablePath := settings.ExecutablePath
+	p := &Plugin{
+		id:             id,
+		executablePath: executablePath,
+		settings:       settings,
+		metadata:       make(map[string]string),
+		apiVersion:     MinPluginAPIVersion,
+	}
 
 	//check if the plugin executable exists
 	_, err := os.Stat(executablePath)
@@ -70,7 +98,12 @@ func NewPluginWithExecutablePath(id string, executablePath string) (*Plugin, err
 
 	//load metadata with the "info" command
 	var buf bytes.Buffer
-	err = p.Command([]string{"info"}, &buf, Stderr, nil).Run()
+	ctx, cancel := operationContext("info")
+	defer cancel()
+	err = procgroup.Run(ctx, p.Command([]string{"info"}, &buf, Stderr, nil))
+	if err != nil && ctx.Err() != nil {
+		return nil, fmt.Errorf("info operation of plugin holo-%s %s", p.id, operationError(ctx, "info"))
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -93,13 +126,19 @@ func NewPluginWithExecutablePath(id string, executablePath string) (*Plugin, err
 	if err != nil {
 		return nil, err
 	}
-	if minVersion > PluginAPIVersion || maxVersion < PluginAPIVersion {
+	if minVersion > MaxPluginAPIVersion || maxVersion < MinPluginAPIVersion {
 		return nil, fmt.Errorf(
-			"plugin holo-%s is incompatible with this Holo (plugin min: %d, plugin max: %d, Holo: %d)",
-			p.id, minVersion, maxVersion, PluginAPIVersion,
+			"plugin holo-%s is incompatible with this Holo (plugin min: %d, plugin max: %d, Holo min: %d, Holo max: %d)",
+			p.id, minVersion, maxVersion, MinPluginAPIVersion, MaxPluginAPIVersion,
 		)
 	}
 
+	//use the newest API version that both sides support
+	p.apiVersion = maxVersion
+	if p.apiVersion > MaxPluginAPIVersion {
+		p.apiVersion = MaxPluginAPIVersion
+	}
+
 	return p, nil
 }
 
@@ -108,6 +147,69 @@ func (p *Plugin) ID() string {
 	return p.id
 }
 
+// PluginReport is the description of a plugin, as shown by `holo plugins`.
+type PluginReport struct {
+	ID                string   `json:"id"`
+	ExecutablePath    string   `json:"executable"`
+	Skipped           bool     `json:"skipped"`
+	SkipReason        string   `json:"skip_reason,omitempty"`
+	Version           string   `json:"version,omitempty"`
+	Description       string   `json:"description,omitempty"`
+	ResourceDirectory string   `json:"resource_dir,omitempty"`
+	StateDirectory    string   `json:"state_dir,omitempty"`
+	Environment       []string `json:"environment"`
+	Conditions        []string `json:"conditions"`
+	MinAPIVersion     int      `json:"min_api_version,omitempty"`
+	MaxAPIVersion     int      `json:"max_api_version,omitempty"`
+	APIVersion        int      `json:"api_version,omitempty"`
+	EntityPrefixes    []string `json:"entity_prefixes"`
+	Operations        []string `json:"optional_operations"`
+}
+
+// Report returns the description of this plugin, mostly from the output of
+// the "info" operation.
+func (p *Plugin) Report() PluginReport {
+	r := PluginReport{
+		ID:                p.id,
+		ExecutablePath:    p.executablePath,
+		Version:           p.metadata["VERSION"],
+		Description:       p.metadata["DESCRIPTION"],
+		ResourceDirectory: p.ResourceDirectory(),
+		StateDirectory:    p.StateDirectory(),
+		Environment:       p.settings.Environment,
+		Conditions:        p.settings.ConditionStrings(),
+		APIVersion:        p.apiVersion,
+		EntityPrefixes:    strings.Fields(p.metadata["ENTITY_PREFIXES"]),
+		Operations:        strings.Fields(p.metadata["OPTIONAL_OPERATIONS"]),
+	}
+	//these were already validated by NewPluginWithExecutablePath()
+	r.MinAPIVersion, _ = strconv.Atoi(p.metadata["MIN_API_VERSION"])
+	r.MaxAPIVersion, _ = strconv.Atoi(p.metadata["MAX_API_VERSION"])
+	//always render lists as arrays, never as null
+	if r.Environment == nil {
+		r.Environment = []string{}
+	}
+	if r.EntityPrefixes == nil {
+		r.EntityPrefixes = []string{}
+	}
+	if r.Operations == nil {
+		r.Operations = []string{}
+	}
+	return r
+}
+
+// SupportsOperation returns whether the plugin implements the given optional
+// operation (e.g. "plan"), as declared by the OPTIONAL_OPERATIONS key in the
+// output of the "info" operation.
+func (p *Plugin) SupportsOperation(operation string) bool {
+	for _, op := range strings.Fields(p.metadata["OPTIONAL_OPERATIONS"]) {
+		if op == operation {
+			return true
+		}
+	}
+	return false
+}
+
 // UseVirtualResourceRoot makes ResourceDirectory() use the VirtualResourceRoot().
 func (p *Plugin) UseVirtualResourceRoot() {
 	p.usesVirtualResourceRoot = true
@@ -116,6 +218,11 @@ func (p *Plugin) UseVirtualResourceRoot() {
 // ResourceDirectory returns the path to the directory where this plugin may
 // find its resources (entity definitions etc.).
 func (p *Plugin) ResourceDirectory() string {
+	//an explicit resource directory from holorc is used as-is (generated
+	//resource files are only available in the default resource directory)
+	if p.settings.ResourceDirectory != "" {
+		return filepath.Join(RootDirectory(), p.settings.ResourceDirectory)
+	}
 	if p.usesVirtualResourceRoot {
 		return filepath.Join(VirtualResourceRoot(), p.id)
 	}
@@ -131,6 +238,9 @@ func (p *Plugin) CacheDirectory() string {
 // StateDirectory returns the path to the directory where this plugin may
 // store persistent data.
 func (p *Plugin) StateDirectory() string {
+	if p.settings.StateDirectory != "" {
+		return filepath.Join(RootDirectory(), p.settings.StateDirectory)
+	}
 	return filepath.Join(RootDirectory(), "var/lib/holo/"+p.id)
 }
 
@@ -146,32 +256,83 @@ func (p *Plugin) Command(arguments []string, stdout io.Writer, stderr io.Writer,
 	cmd := exec.Command(p.executablePath, arguments...)
 	cmd.Stdin = nil
 	cmd.Stdout = stdout
-	cmd.Stderr = &LineColorizingWriter{Writer: stderr, Rules: []LineColorizingRule{
-		{[]byte("!! "), []byte("\x1B[1;31m")},
-		{[]byte(">> "), []byte("\x1B[1;33m")},
-	}}
+	cmd.Stderr = colorizePluginStderr(stderr)
 	if msg != nil {
 		cmd.ExtraFiles = []*os.File{msg}
 	}
 
 	//setup environment
 	env := os.Environ()
-	env = append(env, "HOLO_API_VERSION="+strconv.Itoa(PluginAPIVersion))
+	env = append(env, p.settings.Environment...)
+	env = append(env, "HOLO_API_VERSION="+strconv.Itoa(p.apiVersion))
 	env = append(env, "HOLO_CACHE_DIR="+normalizePath(p.CacheDirectory()))
 	env = append(env, "HOLO_RESOURCE_DIR="+normalizePath(p.ResourceDirectory()))
 	env = append(env, "HOLO_STATE_DIR="+normalizePath(p.StateDirectory()))
+	if journalDir := p.JournalDirectory(); journalDir != "" {
+		env = append(env, "HOLO_JOURNAL_DIR="+normalizePath(journalDir))
+	}
 	if os.Getenv("HOLO_ROOT_DIR") == "" {
 		env = append(env, "HOLO_ROOT_DIR="+normalizePath(RootDirectory()))
 	}
+	env = append(env, colorEnviron())
+	env = append(env, factsEnviron()...)
+	if timeout := currentTimeouts.For("holoscript"); timeout > 0 {
+		env = append(env, "HOLO_HOLOSCRIPT_TIMEOUT="+timeout.String())
+	}
 	cmd.Env = env
 
 	return cmd
 }
 
+// colorizePluginStderr highlights errors and warnings in the stderr of a plugin.
+func colorizePluginStderr(stderr io.Writer) io.Writer {
+	if !UseColors(stderr) {
+		return stderr
+	}
+	return &LineColorizingWriter{Writer: stderr, Rules: []LineColorizingRule{
+		{[]byte("!! "), []byte("\x1B[1;31m")},
+		{[]byte(">> "), []byte("\x1B[1;33m")},
+	}}
+}
+
 // RunCommandWithFD3 extends the Command function with automatic setup and
 // reading of the file-descriptor 3, that is used by some plugin commands to
 // report structured messages to Holo.
+//
+// With plugin API version 4 and above, the operation is executed in the
+// plugin's session instead (which is started on first use). The structured
+// messages are then sent in message frames instead of on file descriptor 3.
+//
+// When the operation times out (or Holo is aborted), the plugin is killed.
 func (p *Plugin) RunCommandWithFD3(arguments []string, stdout, stderr io.Writer) (string, error) {
+	ctx, cancel := operationContext(arguments[0])
+	defer cancel()
+	cmdText, err := p.runCommandWithFD3(ctx, arguments, stdout, stderr)
+	if err != nil && ctx.Err() != nil {
+		err = fmt.Errorf("%s operation %s", arguments[0], operationError(ctx, arguments[0]))
+	}
+	return cmdText, err
+}
+
+func (p *Plugin) runCommandWithFD3(ctx context.Context, arguments []string, stdout, stderr io.Writer) (string, error) {
+	if p.apiVersion >= 4 {
+		if p.session == nil {
+			session, err := p.startSession()
+			if err != nil {
+				return "", err
+			}
+			p.session = session
+		}
+		cmdText, err := p.session.Request(ctx, arguments, stdout, stderr)
+		if _, isExitStatus := err.(sessionExitStatusError); err != nil && !isExitStatus {
+			//the session was killed or is out of sync with us, so a new one
+			//needs to be started on next use
+			p.session.Kill()
+			p.session = nil
+		}
+		return cmdText, err
+	}
+
 	//the command channel (file descriptor 3 on the side of the plugin) can
 	//only be set up with an *os.File instance, so use a pipe that the plugin
 	//writes into and that we read from
@@ -182,7 +343,7 @@ func (p *Plugin) RunCommandWithFD3(arguments []string, stdout, stderr io.Writer)
 
 	//execute apply operation
 	cmd := p.Command(arguments, stdout, stderr, cmdWriterForPlugin)
-	err = cmd.Start() //cannot use Run() since we need to read from the pipe before the plugin exits
+	proc, err := procgroup.Start(ctx, cmd) //cannot use Run() since we need to read from the pipe before the plugin exits
 	if err != nil {
 		return "", err
 	}
@@ -196,7 +357,21 @@ func (p *Plugin) RunCommandWithFD3(arguments []string, stdout, stderr io.Writer)
 	if err != nil {
 		return "", err
 	}
-	return string(cmdBytes), cmd.Wait()
+	return string(cmdBytes), proc.Wait()
+}
+
+// EndSession ends the plugin's session if one was started (with plugin API
+// version 4 and above). Errors are reported on stderr.
+func (p *Plugin) EndSession() {
+	if p.session == nil {
+		return
+	}
+	err := p.session.Close()
+	//when Holo was aborted, the plugin was killed (see HandleInterrupts)
+	if err != nil && abortContext.Err() == nil {
+		Errorf(Stderr, "session with plugin %s failed: %s", p.ID(), err.Error())
+	}
+	p.session = nil
 }
 
 // For reproducibility in tests.
@@ -0,0 +1,71 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+package impl
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPluginReport(t *testing.T) {
+	p := &Plugin{
+		id:             "example",
+		executablePath: "/usr/lib/holo/holo-example",
+		metadata: map[string]string{
+			"MIN_API_VERSION":     "3",
+			"MAX_API_VERSION":     "5",
+			"VERSION":             "1.2",
+			"DESCRIPTION":         "Provisions examples",
+			"ENTITY_PREFIXES":     "example: sample:",
+			"OPTIONAL_OPERATIONS": "plan  check",
+		},
+		apiVersion: 4,
+	}
+	r := p.Report()
+	if r.ID != "example" || r.ExecutablePath != "/usr/lib/holo/holo-example" || r.Skipped {
+		t.Errorf("unexpected identity in report: %#v", r)
+	}
+	if r.Version != "1.2" || r.Description != "Provisions examples" {
+		t.Errorf("unexpected version or description in report: %#v", r)
+	}
+	if r.MinAPIVersion != 3 || r.MaxAPIVersion != 5 || r.APIVersion != 4 {
+		t.Errorf("unexpected API versions in report: %#v", r)
+	}
+	if len(r.EntityPrefixes) != 2 || r.EntityPrefixes[1] != "sample:" {
+		t.Errorf("unexpected entity prefixes in report: %#v", r.EntityPrefixes)
+	}
+	if len(r.Operations) != 2 || r.Operations[1] != "check" {
+		t.Errorf("unexpected operations in report: %#v", r.Operations)
+	}
+
+	//lists must be rendered as empty arrays even without metadata
+	p.metadata = map[string]string{"MIN_API_VERSION": "3", "MAX_API_VERSION": "3"}
+	buf, err := json.Marshal(p.Report())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	json.Unmarshal(buf, &decoded)
+	for _, key := range []string{"environment", "conditions", "entity_prefixes", "optional_operations"} {
+		if _, ok := decoded[key].([]interface{}); !ok {
+			t.Errorf("expected %s to be an array, got %s", key, string(buf))
+		}
+	}
+}
@@ -0,0 +1,188 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/holocm/holo/internal/osrelease"
+)
+
+// PluginSettings contains the options given for a plugin in holorc, on lines
+// of the form "plugin ID[=EXECUTABLE] [OPTION...]".
+type PluginSettings struct {
+	ExecutablePath    string
+	ResourceDirectory string            //from "resource-dir=DIR" (below the root directory), or "" for the default
+	StateDirectory    string            //from "state-dir=DIR" (below the root directory), or "" for the default
+	Environment       []string          //"NAME=value", from "env.NAME=value" options
+	Conditions        []PluginCondition //the plugin is only used when all of these hold
+}
+
+// PluginCondition is an option like "if-hostname=web-*" or "unless-os=arch"
+// that enables or disables a plugin depending on the system.
+type PluginCondition struct {
+	Negated  bool     //"unless-..." instead of "if-..."
+	Subject  string   //"hostname" or "os"
+	Patterns []string //shell-style globs for "hostname", os-release IDs for "os"
+}
+
+// String returns the condition in the form used in holorc.
+func (c PluginCondition) String() string {
+	keyword := "if"
+	if c.Negated {
+		keyword = "unless"
+	}
+	return fmt.Sprintf("%s-%s=%s", keyword, c.Subject, strings.Join(c.Patterns, ","))
+}
+
+// ConditionStrings returns all conditions in the form used in holorc. The
+// result is never nil.
+func (s PluginSettings) ConditionStrings() []string {
+	result := make([]string, 0, len(s.Conditions))
+	for _, condition := range s.Conditions {
+		result = append(result, condition.String())
+	}
+	return result
+}
+
+// Holds returns whether the condition holds on a system with the given
+// hostname and os-release IDs (i.e. the ID and ID_LIKE from os-release(5)).
+func (c PluginCondition) Holds(hostname string, osIDs []string) bool {
+	matches := false
+	for _, pattern := range c.Patterns {
+		switch c.Subject {
+		case "hostname":
+			isMatch, _ := path.Match(pattern, hostname) //pattern was validated by parsePluginLine()
+			matches = matches || isMatch
+		case "os":
+			for _, id := range osIDs {
+				matches = matches || id == pattern
+			}
+		}
+	}
+	return matches != c.Negated
+}
+
+// pluginOptionRx matches the options on a "plugin" line in holorc. Everything
+// before the first option is "ID[=EXECUTABLE]", so the executable path may
+// contain spaces.
+var pluginOptionRx = regexp.MustCompile(`^[a-z][a-z-]*(\.[^=/]*)?=`)
+
+// parsePluginLine parses the arguments of a "plugin" line in holorc.
+func parsePluginLine(args string) (id string, settings PluginSettings, err error) {
+	//take options off the end of the line until only "ID[=EXECUTABLE]" remains
+	head := strings.TrimSpace(args)
+	var options []string
+	for {
+		idx := strings.LastIndexAny(head, " \t")
+		if idx < 0 || !pluginOptionRx.MatchString(head[idx+1:]) {
+			break
+		}
+		options = append([]string{head[idx+1:]}, options...)
+		head = strings.TrimSpace(head[:idx])
+	}
+	if head == "" {
+		return "", settings, fmt.Errorf("missing plugin ID")
+	}
+
+	//the first part is "ID" or "ID=EXECUTABLE"
+	id = head
+	settings.ExecutablePath = DefaultPluginExecutablePath(id)
+	if strings.Contains(id, "=") {
+		parts := strings.SplitN(id, "=", 2)
+		id, settings.ExecutablePath = parts[0], parts[1]
+	}
+	if id == "" {
+		return "", settings, fmt.Errorf("missing plugin ID")
+	}
+	if strings.ContainsAny(id, " \t") {
+		return "", settings, fmt.Errorf("invalid plugin ID or option: %s", id)
+	}
+
+	//the options are of the form "KEY=VALUE"
+	for _, option := range options {
+		parts := strings.SplitN(option, "=", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return "", settings, fmt.Errorf("invalid option for plugin %s: %s", id, option)
+		}
+		key, value := parts[0], parts[1]
+
+		switch {
+		case key == "resource-dir" || key == "state-dir":
+			if !filepath.IsAbs(value) {
+				return "", settings, fmt.Errorf("invalid option for plugin %s: %s is not an absolute path", id, value)
+			}
+			if key == "resource-dir" {
+				settings.ResourceDirectory = filepath.Clean(value)
+			} else {
+				settings.StateDirectory = filepath.Clean(value)
+			}
+		case strings.HasPrefix(key, "env."):
+			name := strings.TrimPrefix(key, "env.")
+			if !variableNameRx.MatchString(name) || strings.HasPrefix(name, "HOLO_") {
+				return "", settings, fmt.Errorf("invalid option for plugin %s: cannot set environment variable %q", id, name)
+			}
+			settings.Environment = append(settings.Environment, name+"="+value)
+		case key == "if-hostname" || key == "unless-hostname" || key == "if-os" || key == "unless-os":
+			parts := strings.SplitN(key, "-", 2)
+			condition := PluginCondition{
+				Negated:  parts[0] == "unless",
+				Subject:  parts[1],
+				Patterns: strings.Split(value, ","),
+			}
+			for _, pattern := range condition.Patterns {
+				_, err := path.Match(pattern, "")
+				if pattern == "" || err != nil {
+					return "", settings, fmt.Errorf("invalid option for plugin %s: invalid pattern %q", id, pattern)
+				}
+			}
+			settings.Conditions = append(settings.Conditions, condition)
+		default:
+			return "", settings, fmt.Errorf("unknown option for plugin %s: %s", id, option)
+		}
+	}
+	return id, settings, nil
+}
+
+// FailedCondition returns the first condition that does not hold on this
+// system, or nil if the plugin shall be used.
+func (s PluginSettings) FailedCondition() *PluginCondition {
+	if len(s.Conditions) == 0 {
+		return nil
+	}
+
+	hostname := readHostname()
+	var osIDs []string
+	if variables, err := osrelease.Read(RootDirectory()); err == nil {
+		osIDs = append(strings.Fields(variables["ID"]), strings.Fields(variables["ID_LIKE"])...)
+	}
+
+	for idx, condition := range s.Conditions {
+		if !condition.Holds(hostname, osIDs) {
+			return &s.Conditions[idx]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePluginLine(t *testing.T) {
+	id, settings, err := parsePluginLine(" files=/opt/holo-files resource-dir=/srv/holo/files/ state-dir=/srv/state env.LOG_LEVEL=debug if-hostname=web-*,db-? unless-os=alpine")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	expected := PluginSettings{
+		ExecutablePath:    "/opt/holo-files",
+		ResourceDirectory: "/srv/holo/files",
+		StateDirectory:    "/srv/state",
+		Environment:       []string{"LOG_LEVEL=debug"},
+		Conditions: []PluginCondition{
+			{Negated: false, Subject: "hostname", Patterns: []string{"web-*", "db-?"}},
+			{Negated: true, Subject: "os", Patterns: []string{"alpine"}},
+		},
+	}
+	if id != "files" || !reflect.DeepEqual(settings, expected) {
+		t.Errorf("unexpected result: %q, %#v", id, settings)
+	}
+	if actual := settings.ConditionStrings(); !reflect.DeepEqual(actual, []string{"if-hostname=web-*,db-?", "unless-os=alpine"}) {
+		t.Errorf("unexpected condition strings: %#v", actual)
+	}
+
+	//without options, the executable is found in the default location
+	id, settings, err = parsePluginLine(" users-groups")
+	if err != nil || id != "users-groups" || settings.ExecutablePath != DefaultPluginExecutablePath("users-groups") {
+		t.Errorf("unexpected result: %q, %#v, %v", id, settings, err)
+	}
+
+	//the executable path may contain whitespace, but options cannot
+	id, settings, err = parsePluginLine(" files=/opt/holo plugins/holo-files  state-dir=/srv/state")
+	if err != nil || id != "files" || settings.ExecutablePath != "/opt/holo plugins/holo-files" || settings.StateDirectory != "/srv/state" {
+		t.Errorf("unexpected result: %q, %#v, %v", id, settings, err)
+	}
+	id, settings, err = parsePluginLine(" foo=/path with spaces/holo-foo")
+	if err != nil || id != "foo" || settings.ExecutablePath != "/path with spaces/holo-foo" {
+		t.Errorf("unexpected result: %q, %#v, %v", id, settings, err)
+	}
+
+	for _, args := range []string{
+		"",
+		"=/opt/holo-files",
+		"files resource-dir",
+		"files resource-dir=relative/path",
+		"files env.HOLO_RESOURCE_DIR=/tmp",
+		"files env.1FOO=bar",
+		"files if-hostname=web[",
+		"files if-os=arch,,debian",
+		"files unknown=option",
+		"files state-dir=/srv/state resource-dir",
+		"two words",
+		"files=/opt/holo-files resource-dir=",
+	} {
+		if _, _, err := parsePluginLine(args); err == nil {
+			t.Errorf("expected error for %q, got nil", args)
+		}
+	}
+}
+
+func TestPluginConditionHolds(t *testing.T) {
+	osIDs := []string{"manjaro", "arch"}
+	testcases := []struct {
+		condition PluginCondition
+		expected  bool
+	}{
+		{PluginCondition{false, "hostname", []string{"web-*"}}, true},
+		{PluginCondition{false, "hostname", []string{"db-*", "web-?"}}, true},
+		{PluginCondition{false, "hostname", []string{"db-*"}}, false},
+		{PluginCondition{true, "hostname", []string{"web-*"}}, false},
+		{PluginCondition{true, "hostname", []string{"db-*"}}, true},
+		{PluginCondition{false, "os", []string{"arch"}}, true},
+		{PluginCondition{false, "os", []string{"debian", "alpine"}}, false},
+		{PluginCondition{true, "os", []string{"debian", "alpine"}}, true},
+		{PluginCondition{true, "os", []string{"manjaro"}}, false},
+	}
+	for _, tc := range testcases {
+		if actual := tc.condition.Holds("web-1", osIDs); actual != tc.expected {
+			t.Errorf("expected %s to hold = %t, got %t", tc.condition, tc.expected, actual)
+		}
+	}
+}
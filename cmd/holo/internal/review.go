@@ -0,0 +1,198 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// ReviewDecision is the answer to the prompt in `holo apply --interactive`.
+type ReviewDecision int
+
+const (
+	//ReviewApply means that the user chose to apply the entity.
+	ReviewApply ReviewDecision = iota
+	//ReviewForceApply means that the user chose to apply the entity with --force.
+	ReviewForceApply
+	//ReviewSkip means that the user chose not to apply the entity.
+	ReviewSkip
+	//ReviewInSync means that the user was not asked because no changes are
+	//pending for the entity.
+	ReviewInSync
+	//ReviewQuit means that the user chose to stop reviewing entities (or that
+	//stdin was closed, or that Holo was interrupted).
+	ReviewQuit
+)
+
+// reviewDiffLines is how many lines of the pending diff are shown before the
+// prompt. The full diff can be requested at the prompt.
+const reviewDiffLines = 20
+
+const reviewHelp = `y - apply this entity
+n - skip this entity
+f - apply this entity with --force
+d - show the full diff
+q - quit; do not apply this entity or any of the remaining ones
+? - print help
+`
+
+// PromptReader reads answers to interactive prompts line by line. Unlike a
+// plain bufio.Reader, it stops waiting for input when Holo is interrupted.
+type PromptReader struct {
+	lines      chan string
+	isTerminal bool
+}
+
+// NewPromptReader starts reading lines from the given file (usually os.Stdin)
+// in the background.
+func NewPromptReader(file *os.File) *PromptReader {
+	p := &PromptReader{lines: make(chan string), isTerminal: isTerminal(file)}
+	go func() {
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			p.lines <- scanner.Text()
+		}
+		close(p.lines)
+	}()
+	return p
+}
+
+// Ask prints the question on stdout and waits for the next line of input.
+// False is returned when the input is closed, or when Holo is interrupted.
+func (p *PromptReader) Ask(question string) (string, bool) {
+	fmt.Fprint(Stdout, question)
+	select {
+	case line, ok := <-p.lines:
+		if !ok {
+			Stdout.Write([]byte{'\n'})
+			return "", false
+		}
+		if p.isTerminal {
+			//the terminal has already echoed the answer and the newline
+			Stdout.Tracker.observeOutput([]byte{'\n'})
+		} else {
+			//echo the answer to make the output readable when it is logged
+			fmt.Fprintln(Stdout, line)
+		}
+		return line, true
+	case <-Interrupts():
+		Stdout.Write([]byte{'\n'})
+		return "", false
+	}
+}
+
+// Review shows the scan report of this entity and the changes that applying
+// it would make (i.e. the diff from its current to its desired version), and
+// asks the user what to do with it.
+func (e *Entity) Review(prompt *PromptReader) ReviewDecision {
+	diff, err := e.Diff("current", "desired")
+	if err == nil && diff != nil && len(diff.Output) == 0 {
+		return ReviewInSync
+	}
+
+	e.PrintReport(true)
+	var diffLines []string
+	switch {
+	case err != nil:
+		Warnf(Stderr, "Cannot show pending changes: %s", err.Error())
+	case diff == nil:
+		Warnf(Stderr, "Cannot show pending changes: plugin %s does not support diffs", e.plugin.ID())
+	default:
+		diffLines = strings.SplitAfter(string(bytes.TrimSuffix(diff.Output, []byte{'\n'})), "\n")
+		e.printReviewDiff(diffLines, reviewDiffLines)
+	}
+
+	for {
+		answer, ok := prompt.Ask(fmt.Sprintf("Apply %s? [y,n,f,d,q,?] ", e.id))
+		if !ok {
+			Stdout.EndParagraph()
+			return ReviewQuit
+		}
+		switch strings.TrimSpace(answer) {
+		case "y":
+			Stdout.EndParagraph()
+			return ReviewApply
+		case "f":
+			Stdout.EndParagraph()
+			return ReviewForceApply
+		case "n":
+			Stdout.EndParagraph()
+			return ReviewSkip
+		case "q":
+			Stdout.EndParagraph()
+			return ReviewQuit
+		case "d":
+			if diffLines == nil {
+				Warnf(Stderr, "No diff available for %s", e.id)
+			} else {
+				e.printReviewDiff(diffLines, len(diffLines))
+			}
+		default:
+			fmt.Fprint(Stdout, reviewHelp)
+		}
+	}
+}
+
+// printReviewDiff prints up to `limit` lines of the pending diff, indented like
+// the diff in the output of Apply().
+func (e *Entity) printReviewDiff(lines []string, limit int) {
+	shown := lines
+	if len(shown) > limit {
+		shown = shown[:limit]
+	}
+	diff := regexp.MustCompile("(?m:^)").ReplaceAllString(strings.Join(shown, ""), "    ")
+	fmt.Fprintln(Stdout, strings.TrimSuffix(diff, "\n"))
+	if len(lines) > limit {
+		fmt.Fprintf(Stdout, "    [... %d more lines, enter \"d\" to show the full diff]\n", len(lines)-limit)
+	}
+	Stdout.EndParagraph()
+}
+
+// ReviewSummary counts the decisions that were made during a single run of
+// `holo apply --interactive`.
+type ReviewSummary map[ReviewDecision]int
+
+// String returns the summary in the form "Decisions: 2 applied, 1 skipped",
+// or an empty string if no entities were reviewed.
+func (s ReviewSummary) String() string {
+	labels := map[ReviewDecision]string{
+		ReviewApply:      "applied",
+		ReviewForceApply: "force-applied",
+		ReviewSkip:       "skipped",
+		ReviewInSync:     "already in sync",
+		ReviewQuit:       "not reviewed",
+	}
+	var fields []string
+	for decision := ReviewApply; decision <= ReviewQuit; decision++ {
+		if count := s[decision]; count > 0 {
+			fields = append(fields, fmt.Sprintf("%d %s", count, labels[decision]))
+		}
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+	return "Decisions: " + strings.Join(fields, ", ")
+}
@@ -0,0 +1,66 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import (
+	"os"
+	"testing"
+)
+
+func TestReviewSummary(t *testing.T) {
+	testcases := []struct {
+		summary  ReviewSummary
+		expected string
+	}{
+		{ReviewSummary{}, ""},
+		{ReviewSummary{ReviewApply: 2, ReviewSkip: 1}, "Decisions: 2 applied, 1 skipped"},
+		{
+			ReviewSummary{ReviewQuit: 4, ReviewInSync: 3, ReviewForceApply: 1},
+			"Decisions: 1 force-applied, 3 already in sync, 4 not reviewed",
+		},
+	}
+	for _, tc := range testcases {
+		actual := tc.summary.String()
+		if actual != tc.expected {
+			t.Errorf("expected %q, got %q", tc.expected, actual)
+		}
+	}
+}
+
+func TestPromptReader(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	prompt := NewPromptReader(r)
+	w.Write([]byte("y\n  n \n"))
+	w.Close()
+
+	for _, expected := range []string{"y", "  n "} {
+		answer, ok := prompt.Ask("")
+		if !ok || answer != expected {
+			t.Errorf("expected answer %q, got %q (ok = %t)", expected, answer, ok)
+		}
+	}
+	if answer, ok := prompt.Ask(""); ok {
+		t.Errorf("expected end of input, got answer %q", answer)
+	}
+}
@@ -46,6 +46,11 @@ func WithCacheDirectory(worker func() (exitCode int)) (exitCode int) {
 		Errorf(Stderr, err.Error())
 		return 255
 	}
+	absVirtualResourceRoot, err = filepath.Abs(virtualResourceRoot)
+	if err != nil {
+		Errorf(Stderr, err.Error())
+		return 255
+	}
 
 	//ensure that the cache is removed even if worker() panics
 	defer func() {
@@ -23,18 +23,95 @@ package impl
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"regexp"
+	"runtime"
 	"sort"
 	"strings"
 )
 
+// ScanAll runs Scan() for all given plugins concurrently, with at most one scan
+// per CPU running at the same time. The stderr of each plugin is buffered and
+// printed once its scan has completed, in the order of the plugins, so that
+// the output of each plugin stays grouped together. The entities are returned
+// in the order of the plugins (and sorted by ID for each plugin). If any scan
+// fails, nil is returned, but the stderr of all plugins is still printed.
+func ScanAll(plugins []*Plugin) []*Entity {
+	type scanResult struct {
+		entities []*Entity
+		stderr   bytes.Buffer
+		done     chan struct{}
+	}
+	results := make([]*scanResult, len(plugins))
+	semaphore := make(chan struct{}, runtime.NumCPU())
+	for idx, plugin := range plugins {
+		result := &scanResult{done: make(chan struct{})}
+		results[idx] = result
+		go func(plugin *Plugin) {
+			semaphore <- struct{}{}
+			result.entities = plugin.Scan(&result.stderr)
+			<-semaphore
+			close(result.done)
+		}(plugin)
+	}
+
+	entities := []*Entity{}
+	hadError := false
+	for _, result := range results {
+		<-result.done
+		//the output of all scans is shown (even after a failed scan, since
+		//the other plugins may have reported errors as well)...
+		if result.stderr.Len() > 0 {
+			Stderr.Write(result.stderr.Bytes())
+		}
+		//...but after a failed scan, entities are not collected anymore
+		if result.entities == nil {
+			hadError = true
+		}
+		if hadError {
+			continue
+		}
+		entities = append(entities, result.entities...)
+		Stdout.EndParagraph()
+	}
+
+	if hadError {
+		return nil
+	}
+	return entities
+}
+
+// Rescan ends the sessions of the given plugins, fills the virtual resource
+// root again (running all generators again), and lets all plugins scan for
+// entities again. This is used by long-running operations like `holo watch`,
+// which would otherwise only ever see the resource files and entities that
+// existed when they were started. Like ScanAll, nil is returned if anything
+// fails.
+func Rescan(plugins []*Plugin) []*Entity {
+	for _, plugin := range plugins {
+		plugin.EndSession()
+	}
+	err := ResetVirtualResourceRoot()
+	if err == nil {
+		err = RunAllGenerators()
+	}
+	if err == nil {
+		err = FinalizeVirtualResourceRoot()
+	}
+	if err != nil {
+		Errorf(Stderr, err.Error())
+		Stderr.EndParagraph()
+		return nil
+	}
+	return ScanAll(plugins)
+}
+
 // Scan discovers entities available for the given entity. Errors are reported
-// immediately and will result in nil being returned. "No entities found" will
-// be reported as a non-nil empty slice.
-// there are no entities.
-func (p *Plugin) Scan() []*Entity {
+// on the given stderr immediately and will result in nil being returned. "No
+// entities found" will be reported as a non-nil empty slice.
+func (p *Plugin) Scan(stderr io.Writer) []*Entity {
 	//invoke scan operation
-	stdout, hadError := p.runScanOperation()
+	stdout, hadError := p.runScanOperation(stderr)
 	if hadError {
 		return nil
 	}
@@ -58,7 +135,7 @@ func (p *Plugin) Scan() []*Entity {
 		//general line format is "key: value"
 		match := lineRx.FindStringSubmatch(line)
 		if match == nil {
-			Errorf(Stderr, "%s: parse error (line was \"%s\")", errorIntro, line)
+			Errorf(stderr, "%s: parse error (line was \"%s\")", errorIntro, line)
 			hadError = true
 			continue
 		}
@@ -74,11 +151,19 @@ func (p *Plugin) Scan() []*Entity {
 		case currentEntity == nil:
 			//if not, we need to be inside an entity
 			//(i.e. line with idx = 0 must start an entity)
-			Errorf(Stderr, "%s: expected entity ID, found attribute \"%s\"", errorIntro, line)
+			Errorf(stderr, "%s: expected entity ID, found attribute \"%s\"", errorIntro, line)
 			hadError = true
 		case key == "SOURCE":
 			value = TranslateIfResourcePath(value)
 			currentEntity.sourceFiles = append(currentEntity.sourceFiles, value)
+		case key == "REQUIRES":
+			currentEntity.requires = append(currentEntity.requires, value)
+		case key == "WATCH":
+			currentEntity.watchPaths = append(currentEntity.watchPaths, value)
+		case key == "TAG":
+			if !currentEntity.hasTag(value) {
+				currentEntity.tags = append(currentEntity.tags, value)
+			}
 		case key == "ACTION":
 			//parse action verb/reason
 			match = actionRx.FindStringSubmatch(value)
@@ -117,12 +202,12 @@ func (p *Plugin) Scan() []*Entity {
 	return result
 }
 
-func (p *Plugin) runScanOperation() (stdout string, hadError bool) {
+func (p *Plugin) runScanOperation(stderr io.Writer) (stdout string, hadError bool) {
 	var stdoutBuffer bytes.Buffer
-	err := p.Command([]string{"scan"}, &stdoutBuffer, Stderr, nil).Run()
+	_, err := p.RunCommandWithFD3([]string{"scan"}, &stdoutBuffer, stderr)
 
 	if err != nil {
-		Errorf(Stderr, "scan with plugin %s failed: %s", p.ID(), err.Error())
+		Errorf(stderr, "scan with plugin %s failed: %s", p.ID(), err.Error())
 	}
 
 	return stdoutBuffer.String(), err != nil
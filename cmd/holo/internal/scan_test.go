@@ -0,0 +1,66 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScanAllReportsErrorsOfAllPlugins(t *testing.T) {
+	dir := t.TempDir()
+	makePlugin := func(id, script string) *Plugin {
+		executablePath := filepath.Join(dir, "holo-"+id)
+		err := os.WriteFile(executablePath, []byte("#!/bin/sh\n"+script), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &Plugin{id: id, executablePath: executablePath, apiVersion: 3}
+	}
+	plugins := []*Plugin{
+		makePlugin("first", "echo 'first failed' >&2\nexit 1\n"),
+		makePlugin("second", "echo 'second failed as well' >&2\nexit 1\n"),
+		makePlugin("third", "echo 'ENTITY: third:a'\n"),
+	}
+
+	defer func(w io.Writer) { Stderr.Writer = w }(Stderr.Writer)
+	var stderr bytes.Buffer
+	Stderr.Writer = &stderr
+
+	WithCacheDirectory(func() int {
+		entities := ScanAll(plugins)
+		if entities != nil {
+			t.Errorf("expected no entities, got %d", len(entities))
+		}
+		return 0
+	})
+
+	//the errors of the second plugin must not be swallowed after the first failed
+	for _, msg := range []string{"first failed", "second failed as well", "scan with plugin second failed"} {
+		if !strings.Contains(stderr.String(), msg) {
+			t.Errorf("expected %q in stderr, got %q", msg, stderr.String())
+		}
+	}
+}
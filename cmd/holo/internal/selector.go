@@ -0,0 +1,212 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Selector represents a command-line argument that selects entities. The Used
+// field tracks whether entities match this selector (to report unrecognized
+// selectors).
+//
+// A selector matches an entity if it is equal to one of the strings returned by
+// Entity.AllMatchingSelectors(), or if it is a pattern matching one of these
+// strings. Patterns are either shell-style globs (any selector containing "*",
+// "?" or "[") or regular expressions (when prefixed with "regex:").
+type Selector struct {
+	Argument string //as given on the command line, e.g. "!file:/etc/ssh/*"
+	String   string //without the "!" prefix, e.g. "file:/etc/ssh/*"
+	Exclude  bool
+	Used     bool
+	Alias    string         //the alias that this selector was expanded from (if any)
+	pattern  *regexp.Regexp //nil for selectors without pattern
+}
+
+// NewSelector parses a selector argument. The "!" prefix (or the exclude
+// argument, for selectors given with "--exclude") turns the selector into an
+// exclusion.
+func NewSelector(argument string, exclude bool) (*Selector, error) {
+	s := &Selector{Argument: argument, String: argument, Exclude: exclude}
+	if strings.HasPrefix(s.String, "!") {
+		s.String = strings.TrimPrefix(s.String, "!")
+		s.Exclude = true
+	}
+	if s.String == "" {
+		return nil, fmt.Errorf("invalid selector %q: empty selector", argument)
+	}
+
+	var err error
+	switch {
+	case strings.HasPrefix(s.String, "regex:"):
+		s.pattern, err = regexp.Compile("^(?:" + strings.TrimPrefix(s.String, "regex:") + ")$")
+	case strings.ContainsAny(s.String, "*?["):
+		s.pattern, err = regexp.Compile(globToRegexp(s.String))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector %q: %s", argument, err.Error())
+	}
+	return s, nil
+}
+
+// Matches returns whether this selector matches the given entity (regardless of
+// whether the selector is an exclusion).
+func (s *Selector) Matches(e *Entity) bool {
+	isMatchingSelector := e.AllMatchingSelectors()
+	if isMatchingSelector[s.String] {
+		return true
+	}
+	if s.pattern == nil {
+		return false
+	}
+	for str := range isMatchingSelector {
+		if s.pattern.MatchString(str) {
+			return true
+		}
+	}
+	return false
+}
+
+// SelectEntities returns those entities which match any of the given
+// selectors, except for those matching any exclusion. When no selectors (or
+// only exclusions) are given, all entities are selected, except for those
+// matching any exclusion. The Used field of all given selectors is updated.
+func SelectEntities(entities []*Entity, selectors []*Selector) []*Entity {
+	hasInclusions := false
+	for _, selector := range selectors {
+		if !selector.Exclude {
+			hasInclusions = true
+		}
+	}
+
+	result := make([]*Entity, 0, len(entities))
+	for _, entity := range entities {
+		isIncluded := !hasInclusions
+		isExcluded := false
+		for _, selector := range selectors {
+			//NOTE: don't break from the selectors loop; we want to look at every
+			//selector because this loop also verifies that selectors are valid
+			if selector.Matches(entity) {
+				selector.Used = true
+				if selector.Exclude {
+					isExcluded = true
+				} else {
+					isIncluded = true
+				}
+			}
+		}
+		if isIncluded && !isExcluded {
+			result = append(result, entity)
+		}
+	}
+	return result
+}
+
+// ExpandAliases replaces all selectors that name an alias (as defined by
+// "alias NAME = SELECTOR..." lines in holorc) by the selectors that the alias
+// stands for. Aliases can refer to other aliases. An exclusion of an alias
+// (e.g. "!ssh") excludes everything that the alias selects.
+func ExpandAliases(selectors []*Selector, aliases map[string][]string) ([]*Selector, error) {
+	result := make([]*Selector, 0, len(selectors))
+	for _, selector := range selectors {
+		if _, isAlias := aliases[selector.String]; !isAlias {
+			result = append(result, selector)
+			continue
+		}
+		expanded, err := expandAlias(selector.String, selector.Exclude, aliases, nil)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, expanded...)
+	}
+	return result, nil
+}
+
+// expandAlias is the recursive part of ExpandAliases. The stack contains the
+// aliases that are currently being expanded, to detect cycles.
+func expandAlias(name string, exclude bool, aliases map[string][]string, stack []string) ([]*Selector, error) {
+	for idx, outerName := range stack {
+		if outerName == name {
+			cycle := append(stack[idx:len(stack):len(stack)], name)
+			return nil, fmt.Errorf("alias %s refers to itself: %s", name, strings.Join(cycle, " -> "))
+		}
+	}
+	stack = append(stack, name)
+
+	var result []*Selector
+	for _, member := range aliases[name] {
+		if _, isAlias := aliases[member]; isAlias {
+			expanded, err := expandAlias(member, exclude, aliases, stack)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, expanded...)
+			continue
+		}
+		selector, err := NewSelector(member, exclude)
+		if err != nil {
+			return nil, fmt.Errorf("in alias %s: %s", name, err.Error())
+		}
+		selector.Alias = name
+		result = append(result, selector)
+	}
+	return result, nil
+}
+
+// globToRegexp converts a shell-style glob into a regular expression. "*" and
+// "?" do not match slashes, but "**" matches any string including slashes.
+func globToRegexp(glob string) string {
+	var buf strings.Builder
+	buf.WriteString("^")
+	for idx := 0; idx < len(glob); idx++ {
+		switch c := glob[idx]; c {
+		case '*':
+			if idx+1 < len(glob) && glob[idx+1] == '*' {
+				buf.WriteString(".*")
+				idx++
+			} else {
+				buf.WriteString("[^/]*")
+			}
+		case '?':
+			buf.WriteString("[^/]")
+		case '[':
+			//copy character class verbatim (with "[!...]" as alternative
+			//spelling of "[^...]"), or match a literal "[" if not terminated
+			end := strings.IndexByte(glob[idx+1:], ']')
+			if end == -1 {
+				buf.WriteString(`\[`)
+				continue
+			}
+			class := glob[idx+1 : idx+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			buf.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			idx += end + 1
+		default:
+			buf.WriteString(regexp.QuoteMeta(glob[idx : idx+1]))
+		}
+	}
+	buf.WriteString("$")
+	return buf.String()
+}
@@ -0,0 +1,172 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGlobToRegexp(t *testing.T) {
+	testcases := []struct {
+		glob     string
+		input    string
+		expected bool
+	}{
+		{"file:/etc/ssh/*", "file:/etc/ssh/sshd_config", true},
+		{"file:/etc/ssh/*", "file:/etc/ssh/ssh_config.d/foo.conf", false},
+		{"file:/etc/ssh/**", "file:/etc/ssh/ssh_config.d/foo.conf", true},
+		{"file:/etc/*.conf", "file:/etc/foo.conf", true},
+		{"file:/etc/*.conf", "file:/etc/fooXconf", false},
+		{"user:?lice", "user:alice", true},
+		{"user:?lice", "user:lice", false},
+		{"user:[ab]*", "user:bob", true},
+		{"user:[!ab]*", "user:bob", false},
+		{"user:[!ab]*", "user:carol", true},
+		{"user:[ab", "user:[ab", true},
+	}
+	for _, tc := range testcases {
+		s, err := NewSelector(tc.glob, false)
+		if err != nil {
+			t.Errorf("unexpected error for %q: %s", tc.glob, err.Error())
+			continue
+		}
+		if actual := s.pattern.MatchString(tc.input); actual != tc.expected {
+			t.Errorf("expected %q to match %q = %t, got %t", tc.glob, tc.input, tc.expected, actual)
+		}
+	}
+}
+
+func TestSelectEntities(t *testing.T) {
+	plugin := &Plugin{id: "users-groups"}
+	var entities []*Entity
+	for _, id := range []string{"user:alice", "user:bob", "user:carol", "group:wheel"} {
+		entities = append(entities, &Entity{plugin: plugin, id: id})
+	}
+	entities[0].tags = []string{"admin"}
+	entities[3].tags = []string{"admin", "wheel"}
+
+	testcases := []struct {
+		selectors []string
+		expected  string
+		unused    string
+	}{
+		{[]string{"user:bob"}, "user:bob", ""},
+		{[]string{"user:*", "!user:alice"}, "user:bob user:carol", ""},
+		{[]string{"!user:alice"}, "user:bob user:carol group:wheel", ""},
+		{[]string{"users-groups", "!group:*"}, "user:alice user:bob user:carol", ""},
+		{[]string{"regex:user:(alice|bob)"}, "user:alice user:bob", ""},
+		//regexes must match the whole selector
+		{[]string{"regex:user:a"}, "", "regex:user:a"},
+		{[]string{"user:*", "file:*"}, "user:alice user:bob user:carol", "file:*"},
+		{[]string{"tag:admin"}, "user:alice group:wheel", ""},
+		{[]string{"tag:admin", "!group:*"}, "user:alice", ""},
+		{[]string{"tag:w*"}, "group:wheel", ""},
+		{[]string{"tag:nginx"}, "", "tag:nginx"},
+	}
+	for _, tc := range testcases {
+		var selectors []*Selector
+		for _, arg := range tc.selectors {
+			s, err := NewSelector(arg, false)
+			if err != nil {
+				t.Fatalf("unexpected error for %q: %s", arg, err.Error())
+			}
+			selectors = append(selectors, s)
+		}
+		if actual := entityIDs(SelectEntities(entities, selectors)); actual != tc.expected {
+			t.Errorf("expected selection %q for %v, got %q", tc.expected, tc.selectors, actual)
+		}
+		var unused []string
+		for _, s := range selectors {
+			if !s.Used {
+				unused = append(unused, s.Argument)
+			}
+		}
+		if actual := strings.Join(unused, " "); actual != tc.unused {
+			t.Errorf("expected unused selectors %q for %v, got %q", tc.unused, tc.selectors, actual)
+		}
+	}
+}
+
+func TestInvalidSelector(t *testing.T) {
+	for _, arg := range []string{"", "!", "regex:user:(alice"} {
+		if _, err := NewSelector(arg, false); err == nil {
+			t.Errorf("expected error for selector %q, got nil", arg)
+		}
+	}
+}
+
+func TestExpandAliases(t *testing.T) {
+	aliases := map[string][]string{
+		"admins": {"user:alice", "group:wheel"},
+		"people": {"admins", "user:b*"},
+		"cycle1": {"user:alice", "cycle2"},
+		"cycle2": {"cycle1"},
+	}
+
+	testcases := []struct {
+		selectors []string
+		expected  string
+	}{
+		{[]string{"user:carol"}, "user:carol"},
+		{[]string{"admins"}, "user:alice group:wheel"},
+		{[]string{"people", "user:carol"}, "user:alice group:wheel user:b* user:carol"},
+		{[]string{"!admins"}, "!user:alice !group:wheel"},
+	}
+	for _, tc := range testcases {
+		var selectors []*Selector
+		for _, arg := range tc.selectors {
+			s, err := NewSelector(arg, false)
+			if err != nil {
+				t.Fatalf("unexpected error for %q: %s", arg, err.Error())
+			}
+			selectors = append(selectors, s)
+		}
+		expanded, err := ExpandAliases(selectors, aliases)
+		if err != nil {
+			t.Errorf("unexpected error for %v: %s", tc.selectors, err.Error())
+			continue
+		}
+		var actual []string
+		for _, s := range expanded {
+			if s.Exclude {
+				actual = append(actual, "!"+s.String)
+			} else {
+				actual = append(actual, s.String)
+			}
+		}
+		if strings.Join(actual, " ") != tc.expected {
+			t.Errorf("expected %v to expand to %q, got %q", tc.selectors, tc.expected, strings.Join(actual, " "))
+		}
+	}
+
+	//members remember the alias that defines them (for error messages)
+	expanded, _ := ExpandAliases([]*Selector{{String: "people"}}, aliases)
+	if expanded[0].Alias != "admins" || expanded[2].Alias != "people" {
+		t.Errorf("unexpected aliases on expanded selectors: %q, %q", expanded[0].Alias, expanded[2].Alias)
+	}
+
+	_, err := ExpandAliases([]*Selector{{String: "cycle1"}}, aliases)
+	expectedError := "alias cycle1 refers to itself: cycle1 -> cycle2 -> cycle1"
+	if err == nil || err.Error() != expectedError {
+		t.Errorf("expected error %q, got %v", expectedError, err)
+	}
+}
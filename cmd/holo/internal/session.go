@@ -0,0 +1,177 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/holocm/holo/internal/pluginapi"
+	"github.com/holocm/holo/internal/procgroup"
+)
+
+// pluginSession is a plugin process that was started with the "serve"
+// operation (plugin API version 4), and which executes all further operations
+// that Holo requests.
+type pluginSession struct {
+	proc   *procgroup.Process
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+	mutex  sync.Mutex
+}
+
+func (p *Plugin) startSession() (*pluginSession, error) {
+	cmd := p.Command([]string{"serve"}, nil, Stderr, nil)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	//the session lives as long as Holo, unless Holo is aborted
+	proc, err := procgroup.Start(abortContext, cmd)
+	if err != nil {
+		return nil, err
+	}
+	return &pluginSession{proc: proc, stdin: stdin, stdout: bufio.NewReader(stdout)}, nil
+}
+
+// sessionExitStatusError is returned by pluginSession.Request when the
+// operation failed with a non-zero exit status. This is the only kind of
+// error after which the session can still be used.
+type sessionExitStatusError struct {
+	exitCode int
+}
+
+// Error implements the error interface.
+func (e sessionExitStatusError) Error() string {
+	return fmt.Sprintf("exit status %d", e.exitCode)
+}
+
+// Request executes an operation in the plugin session. The return values
+// are the same as for Plugin.RunCommandWithFD3(). When the context is done
+// before the operation has completed, the plugin is killed. After any error
+// other than a sessionExitStatusError, the session cannot be used anymore
+// (since unread frames of this operation would be mistaken for the response
+// to the next one), and must be discarded with Kill().
+func (s *pluginSession) Request(ctx context.Context, arguments []string, stdout, stderr io.Writer) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	//the context is also done when the caller cleans up after the request has
+	//completed, or when the deadline expires right after the response was
+	//read, which must not kill the plugin
+	var (
+		stateMutex sync.Mutex
+		completed  bool
+		killed     bool
+	)
+	complete := func() bool {
+		stateMutex.Lock()
+		defer stateMutex.Unlock()
+		completed = true
+		return !killed
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			stateMutex.Lock()
+			defer stateMutex.Unlock()
+			if !completed {
+				killed = true
+				s.proc.Kill()
+			}
+		case <-done:
+		}
+	}()
+
+	err := pluginapi.WriteFrame(s.stdin, pluginapi.FrameRequest, pluginapi.EncodeArguments(arguments))
+	if err != nil {
+		return "", err
+	}
+
+	stderr = colorizePluginStderr(stderr)
+	var msg []byte
+	for {
+		frameType, payload, err := pluginapi.ReadFrame(s.stdout)
+		if err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				err = fmt.Errorf("plugin exited unexpectedly")
+			}
+			return string(msg), err
+		}
+
+		switch frameType {
+		case pluginapi.FrameStdout:
+			_, err = stdout.Write(payload)
+		case pluginapi.FrameStderr:
+			_, err = stderr.Write(payload)
+		case pluginapi.FrameMessage:
+			msg = append(msg, payload...)
+		case pluginapi.FrameStatus:
+			//if the plugin was killed after sending its response, the session
+			//is broken nonetheless
+			if !complete() {
+				return string(msg), ctx.Err()
+			}
+			exitCode, err := strconv.Atoi(string(payload))
+			if err != nil {
+				return string(msg), fmt.Errorf("invalid exit code in %s frame: %q", frameType, string(payload))
+			}
+			if exitCode != 0 {
+				return string(msg), sessionExitStatusError{exitCode}
+			}
+			return string(msg), nil
+		default:
+			err = fmt.Errorf("unexpected %s frame", frameType)
+		}
+		if err != nil {
+			return string(msg), err
+		}
+	}
+}
+
+// Kill ends a session that cannot be used anymore by killing the plugin
+// process, and waits for it to exit.
+func (s *pluginSession) Kill() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.proc.Kill()
+	s.stdin.Close()
+	_ = s.proc.Wait() //the error is expected since the plugin was killed
+}
+
+// Close ends the session by closing the plugin's stdin, and waits for the
+// plugin process to exit.
+func (s *pluginSession) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.stdin.Close()
+	return s.proc.Wait()
+}
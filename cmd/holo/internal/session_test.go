@@ -0,0 +1,135 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// The first session of this plugin answers with a frame that Holo does not
+// understand, followed by frames that must not be mistaken for the response to
+// the next request. All later sessions answer every request with exit code 3.
+const sessionTestPlugin = `#!/bin/sh
+while read -r header; do
+  head -c "${header#* }" > /dev/null
+  if [ ! -f "$0.started" ]; then
+    touch "$0.started"
+    printf 'bogus 0\nstdout 5\nstale'
+  else
+    printf 'stdout 5\nfresh'
+  fi
+  printf 'status 1\n3'
+done
+`
+
+func TestSessionResetAfterProtocolError(t *testing.T) {
+	executablePath := filepath.Join(t.TempDir(), "holo-session-test")
+	err := os.WriteFile(executablePath, []byte(sessionTestPlugin), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &Plugin{id: "session-test", executablePath: executablePath, apiVersion: 4}
+	WithCacheDirectory(func() int {
+		defer p.EndSession()
+		testSessionReset(t, p)
+		return 0
+	})
+}
+
+func testSessionReset(t *testing.T, p *Plugin) {
+
+	var stdout, stderr bytes.Buffer
+	_, err := p.RunCommandWithFD3([]string{"scan"}, &stdout, &stderr)
+	if err == nil || err.Error() != "unexpected bogus frame" {
+		t.Errorf("expected protocol error, got %v", err)
+	}
+	if p.session != nil {
+		t.Fatal("expected broken session to be discarded")
+	}
+
+	//a new session is started, and a non-zero exit status does not break it
+	for idx := 0; idx < 2; idx++ {
+		stdout.Reset()
+		_, err = p.RunCommandWithFD3([]string{"apply", "example"}, &stdout, &stderr)
+		if err == nil || err.Error() != "exit status 3" {
+			t.Errorf("expected exit status 3, got %v", err)
+		}
+		if stdout.String() != "fresh" {
+			t.Errorf("expected output %q, got %q", "fresh", stdout.String())
+		}
+		if p.session == nil {
+			t.Error("expected session to be kept after non-zero exit status")
+		}
+	}
+}
+
+// cancelingWriter cancels a context when output is written to it, and gives
+// the session's watcher some time to notice before the response is read
+// further.
+type cancelingWriter struct {
+	cancel context.CancelFunc
+}
+
+// Write implements the io.Writer interface.
+func (w cancelingWriter) Write(p []byte) (int, error) {
+	w.cancel()
+	time.Sleep(10 * time.Millisecond)
+	return len(p), nil
+}
+
+func TestSessionNotKilledAfterResponse(t *testing.T) {
+	executablePath := filepath.Join(t.TempDir(), "holo-session-test")
+	script := "#!/bin/sh\nwhile read -r header; do\n  head -c \"${header#* }\" > /dev/null\n  printf 'stdout 1\\nxstatus 1\\n0'\ndone\n"
+	err := os.WriteFile(executablePath, []byte(script), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &Plugin{id: "session-test", executablePath: executablePath, apiVersion: 4}
+
+	//the context is done while the response is read, so the plugin is either
+	//killed (and the request fails) or the request succeeds (and the session
+	//must still be usable)
+	WithCacheDirectory(func() int {
+		for idx := 0; idx < 10; idx++ {
+			session, err := p.startSession()
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			var stderr bytes.Buffer
+			_, err = session.Request(ctx, []string{"scan"}, cancelingWriter{cancel}, &stderr)
+			if err == nil {
+				var stdout bytes.Buffer
+				_, err = session.Request(context.Background(), []string{"scan"}, &stdout, &stderr)
+				if err != nil {
+					t.Fatalf("session was broken after successful request: %s", err.Error())
+				}
+			}
+			session.Kill()
+		}
+		return 0
+	})
+}
@@ -0,0 +1,116 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Exit codes of `holo apply`, `holo check` and `holo diff`, as documented in holo(8).
+const (
+	//ExitSuccess means that all selected entities were processed successfully.
+	ExitSuccess = 0
+	//ExitErrors means that errors occurred for some entities.
+	ExitErrors = 1
+	//ExitUsage means that the command line could not be parsed.
+	ExitUsage = 2
+	//ExitRequiresForce means that no errors occurred, but some entities were
+	//not provisioned because they require --force.
+	ExitRequiresForce = 3
+	//ExitNotInSync means that `holo check` found entities that are not in
+	//sync with their desired state.
+	ExitNotInSync = 4
+	//ExitInterrupted means that Holo was interrupted by SIGINT or SIGTERM, so
+	//some entities were not processed.
+	ExitInterrupted = 130
+	//ExitFatal means that a fatal error occurred before any entities could be
+	//processed (e.g. invalid configuration, failed scan, unrecognized selectors).
+	ExitFatal = 255
+)
+
+// ApplySummary counts the outcomes of Entity.Apply() or Entity.Plan() during
+// a single run of `holo apply`.
+type ApplySummary struct {
+	DryRun bool
+	counts map[ApplyOutcome]int
+}
+
+// NewApplySummary counts the outcomes that were recorded for the given
+// entities. Entities that were not applied (or planned or skipped) are
+// ignored.
+func NewApplySummary(entities []*Entity, dryRun bool) ApplySummary {
+	s := ApplySummary{DryRun: dryRun, counts: make(map[ApplyOutcome]int)}
+	for _, entity := range entities {
+		if outcome, ok := entity.Outcome(); ok {
+			s.counts[outcome]++
+		}
+	}
+	return s
+}
+
+// Count returns how many entities had the given outcome.
+func (s ApplySummary) Count(outcome ApplyOutcome) int {
+	return s.counts[outcome]
+}
+
+// String returns the summary in the form "Summary: 2 changed, 10 unchanged",
+// or an empty string if no entities were processed.
+func (s ApplySummary) String() string {
+	var fields []string
+	for outcome := ApplyChanged; outcome <= ApplyDeclined; outcome++ {
+		count := s.counts[outcome]
+		if count == 0 {
+			continue
+		}
+		label := outcome.String()
+		switch outcome {
+		case ApplyChanged:
+			if s.DryRun {
+				label = "would be changed"
+			}
+		case ApplyRequiresForceToOverwrite:
+			label = "need --force to overwrite"
+		case ApplyRequiresForceToRestore:
+			label = "need --force to restore"
+		case ApplyUnknown:
+			label = "cannot be planned"
+		}
+		fields = append(fields, fmt.Sprintf("%d %s", count, label))
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+	return "Summary: " + strings.Join(fields, ", ")
+}
+
+// ExitCode returns the exit code for `holo apply` that corresponds to this
+// summary. Errors take precedence over entities that require --force.
+func (s ApplySummary) ExitCode() int {
+	switch {
+	case s.counts[ApplyFailed] > 0 || s.counts[ApplySkipped] > 0:
+		return ExitErrors
+	case s.counts[ApplyRequiresForceToOverwrite] > 0 || s.counts[ApplyRequiresForceToRestore] > 0:
+		return ExitRequiresForce
+	default:
+		return ExitSuccess
+	}
+}
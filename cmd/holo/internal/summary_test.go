@@ -0,0 +1,70 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import "testing"
+
+func makeSummaryForTest(dryRun bool, outcomes ...ApplyOutcome) ApplySummary {
+	var entities []*Entity
+	for _, outcome := range outcomes {
+		entity := &Entity{}
+		entity.recordOutcome(outcome, nil)
+		entities = append(entities, entity)
+	}
+	//entities without outcome are not counted
+	entities = append(entities, &Entity{})
+	return NewApplySummary(entities, dryRun)
+}
+
+func TestApplySummary(t *testing.T) {
+	testcases := []struct {
+		summary          ApplySummary
+		expectedString   string
+		expectedExitCode int
+	}{
+		{makeSummaryForTest(false), "", ExitSuccess},
+		{
+			makeSummaryForTest(false, ApplyUnchanged, ApplyChanged, ApplyUnchanged),
+			"Summary: 1 changed, 2 unchanged", ExitSuccess,
+		},
+		{
+			makeSummaryForTest(true, ApplyChanged, ApplyRequiresForceToRestore, ApplyUnknown),
+			"Summary: 1 would be changed, 1 need --force to restore, 1 cannot be planned", ExitRequiresForce,
+		},
+		{
+			makeSummaryForTest(false, ApplyRequiresForceToOverwrite, ApplyFailed, ApplySkipped),
+			"Summary: 1 need --force to overwrite, 1 failed, 1 skipped", ExitErrors,
+		},
+		{
+			//entities that the user chose to skip are not an error
+			makeSummaryForTest(false, ApplyDeclined, ApplyChanged),
+			"Summary: 1 changed, 1 declined", ExitSuccess,
+		},
+	}
+	for idx, tc := range testcases {
+		if actual := tc.summary.String(); actual != tc.expectedString {
+			t.Errorf("testcase %d: expected summary %q, got %q", idx, tc.expectedString, actual)
+		}
+		if actual := tc.summary.ExitCode(); actual != tc.expectedExitCode {
+			t.Errorf("testcase %d: expected exit code %d, got %d", idx, tc.expectedExitCode, actual)
+		}
+	}
+}
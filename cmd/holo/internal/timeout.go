@@ -0,0 +1,114 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeoutOperations are the operations that timeouts can be configured for:
+// the plugin operations, plus the runs of generators and holoscripts.
+var timeoutOperations = []string{
+	"info", "scan", "apply", "force-apply", "plan", "force-plan",
+	"diff", "check", "explain", "rollback", "generator", "holoscript",
+}
+
+// Timeouts maps operations (see timeoutOperations) to the maximum duration
+// that they may take. The empty key holds the default for all operations.
+// A zero duration means that there is no timeout.
+type Timeouts map[string]time.Duration
+
+// Set parses a timeout specification of the form "[OPERATION=]DURATION" (as
+// given in holorc or with the --timeout option) and records it.
+func (t Timeouts) Set(spec string) error {
+	operation, value := "", spec
+	if strings.Contains(spec, "=") {
+		fields := strings.SplitN(spec, "=", 2)
+		operation, value = strings.TrimSpace(fields[0]), strings.TrimSpace(fields[1])
+		isKnown := false
+		for _, op := range timeoutOperations {
+			if op == operation {
+				isKnown = true
+			}
+		}
+		if !isKnown {
+			return fmt.Errorf("unknown operation %q (valid operations: %s)", operation, strings.Join(timeoutOperations, ", "))
+		}
+	}
+	timeout, err := ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	t[operation] = timeout
+	return nil
+}
+
+// For returns the timeout for the given operation, or 0 if there is none.
+func (t Timeouts) For(operation string) time.Duration {
+	if timeout, exists := t[operation]; exists {
+		return timeout
+	}
+	return t[""]
+}
+
+// ParseDuration parses a duration that is either given as a number of seconds
+// or in a format like "1m30s".
+func ParseDuration(value string) (time.Duration, error) {
+	if seconds, err := strconv.ParseUint(value, 10, 32); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err == nil && duration < 0 {
+		err = errors.New("duration must not be negative")
+	}
+	return duration, err
+}
+
+// The timeouts used by operationContext().
+var currentTimeouts = Timeouts{}
+
+// SetTimeouts sets the timeouts for all operations that are started from now on.
+func SetTimeouts(timeouts Timeouts) {
+	currentTimeouts = timeouts
+}
+
+// operationContext returns the context in which the given operation shall be
+// executed. The context is done when the operation's timeout expires, or when
+// Holo is aborted (see HandleInterrupts).
+func operationContext(operation string) (context.Context, context.CancelFunc) {
+	if timeout := currentTimeouts.For(operation); timeout > 0 {
+		return context.WithTimeout(abortContext, timeout)
+	}
+	return context.WithCancel(abortContext)
+}
+
+// operationError describes why the context of the given operation is done.
+func operationError(ctx context.Context, operation string) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s", currentTimeouts.For(operation))
+	}
+	return errors.New("aborted")
+}
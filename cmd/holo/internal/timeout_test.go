@@ -0,0 +1,54 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeouts(t *testing.T) {
+	timeouts := make(Timeouts)
+	for _, spec := range []string{"30", "apply=5m", "holoscript = 1m30s", "scan=0", "explain=10s"} {
+		err := timeouts.Set(spec)
+		if err != nil {
+			t.Errorf("unexpected error for %q: %s", spec, err.Error())
+		}
+	}
+	expected := map[string]time.Duration{
+		"apply":       5 * time.Minute,
+		"force-apply": 30 * time.Second, //falls back to the default
+		"holoscript":  90 * time.Second,
+		"scan":        0, //explicitly disabled
+		"explain":     10 * time.Second,
+	}
+	for operation, timeout := range expected {
+		if actual := timeouts.For(operation); actual != timeout {
+			t.Errorf("expected timeout %s for %s, got %s", timeout, operation, actual)
+		}
+	}
+
+	for _, spec := range []string{"frobnicate=5s", "apply=", "-5s", "forever"} {
+		if err := timeouts.Set(spec); err == nil {
+			t.Errorf("expected error for %q, got none", spec)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// watchMask selects the inotify events that indicate that a file in a
+// watched directory was changed.
+const watchMask = syscall.IN_CLOSE_WRITE | syscall.IN_ATTRIB | syscall.IN_CREATE | syscall.IN_DELETE |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF
+
+// Watcher reports changes to a set of paths using inotify(7).
+//
+// Since files are often replaced instead of being written in place (e.g. by
+// editors, or by useradd(8) for /etc/passwd), the directory containing each
+// path is watched instead of the path itself. If that directory does not exist
+// (yet), its closest existing ancestor is watched instead.
+type Watcher struct {
+	file  *os.File
+	mutex sync.Mutex //protects paths and dirs (Next() runs concurrently to SetPaths())
+	paths []string
+	dirs  map[int32]string //watch descriptor -> watched directory
+}
+
+// NewWatcher creates a Watcher for the given paths.
+func NewWatcher(paths []string) (*Watcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	w := &Watcher{
+		//since the fd is non-blocking, reads go through the runtime's poller
+		//and are interrupted by Close()
+		file: os.NewFile(uintptr(fd), "inotify"),
+		dirs: make(map[int32]string),
+	}
+	err = w.SetPaths(paths)
+	if err != nil {
+		w.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// SetPaths replaces the set of watched paths. Directories that were watched
+// for the previous paths stay watched, but changes below them are only
+// reported if they affect one of the new paths.
+func (w *Watcher) SetPaths(paths []string) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.paths = make([]string, len(paths))
+	for idx, path := range paths {
+		w.paths[idx] = filepath.Clean(path)
+	}
+	return w.refresh()
+}
+
+// refresh ensures that the closest existing ancestor of each path is watched.
+// This is repeated after each change since directories may have been created
+// or removed.
+func (w *Watcher) refresh() error {
+	isWatched := make(map[string]bool)
+	for _, dir := range w.dirs {
+		isWatched[dir] = true
+	}
+	for _, path := range w.paths {
+		dir := closestExistingDirectory(filepath.Dir(path))
+		if isWatched[dir] {
+			continue
+		}
+		wd, err := syscall.InotifyAddWatch(int(w.file.Fd()), dir, watchMask)
+		if err != nil {
+			return &os.PathError{Op: "inotify_add_watch", Path: dir, Err: err}
+		}
+		w.dirs[int32(wd)] = dir
+		isWatched[dir] = true
+	}
+	return nil
+}
+
+func closestExistingDirectory(dir string) string {
+	for {
+		fi, err := os.Stat(dir)
+		if err == nil && fi.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// Next blocks until changes occur, and returns the watched paths that were
+// affected by them (in the order in which they were given to NewWatcher).
+func (w *Watcher) Next() ([]string, error) {
+	var buf [64 * (syscall.SizeofInotifyEvent + syscall.NAME_MAX + 1)]byte
+	for {
+		n, err := w.file.Read(buf[:])
+		if err != nil {
+			return nil, err
+		}
+		affected, err := w.processEvents(buf[:n])
+		if err != nil {
+			return nil, err
+		}
+		if len(affected) > 0 {
+			return affected, nil
+		}
+	}
+}
+
+// processEvents handles the inotify events read by Next(), and returns the
+// affected watched paths.
+func (w *Watcher) processEvents(buf []byte) ([]string, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	var changedPaths []string
+	for offset := 0; offset+syscall.SizeofInotifyEvent <= len(buf); {
+		event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+		nameBytes := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(event.Len)]
+		offset += syscall.SizeofInotifyEvent + int(event.Len)
+
+		dir, exists := w.dirs[event.Wd]
+		if !exists {
+			continue
+		}
+		if event.Mask&syscall.IN_IGNORED != 0 {
+			//the watched directory is gone
+			delete(w.dirs, event.Wd)
+		}
+		name := strings.TrimRight(string(nameBytes), "\000")
+		if name == "" {
+			//event for the watched directory itself
+			changedPaths = append(changedPaths, dir)
+		} else {
+			changedPaths = append(changedPaths, filepath.Join(dir, name))
+		}
+	}
+
+	err := w.refresh()
+	if err != nil {
+		return nil, err
+	}
+	return w.affectedPaths(changedPaths), nil
+}
+
+// affectedPaths returns the watched paths that are, or are below, any of the
+// given changed paths.
+func (w *Watcher) affectedPaths(changedPaths []string) []string {
+	var result []string
+	for _, path := range w.paths {
+		for _, changedPath := range changedPaths {
+			if path == changedPath || strings.HasPrefix(path, changedPath+"/") {
+				result = append(result, path)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// Close releases the inotify instance. Any call to Next() that is blocked at
+// that point returns with an error.
+func (w *Watcher) Close() error {
+	return w.file.Close()
+}
+
+// WatchEntities watches the paths that the given entities declared in their
+// scan reports (with the WATCH key), and calls the handler with the entities
+// whose paths were changed. Changes are collected until no more changes have
+// occurred for the given quiet period, so that the handler is called only
+// once for a burst of changes (e.g. while a package manager is running).
+//
+// Before the handler is called, the rescan function is called to find the
+// current entities, since resource files may have been added or removed in the
+// meantime. The handler is then called with those current entities whose paths
+// were changed, plus all entities that did not exist before. From then on, the
+// paths of the current entities are watched. If the rescan function returns
+// nil, the previous entities are used instead.
+//
+// WatchEntities returns when the stop channel is closed (usually, this is
+// Interrupts()), or when the paths cannot be watched anymore.
+func WatchEntities(entities []*Entity, quietPeriod time.Duration, stop <-chan struct{}, rescan func() []*Entity, handler func([]*Entity)) error {
+	paths, entitiesForPath := watchPathsOf(entities)
+	watcher, err := NewWatcher(paths)
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	changes := make(chan []string)
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			changedPaths, err := watcher.Next()
+			if err != nil {
+				errs <- err
+				return
+			}
+			changes <- changedPaths
+		}
+	}()
+
+	isChanged := make(map[string]bool) //entity ID -> whether a watched path was changed
+	var timer <-chan time.Time
+	for {
+		select {
+		case <-stop:
+			return nil
+		case err := <-errs:
+			return err
+		case changedPaths := <-changes:
+			for _, path := range changedPaths {
+				for _, entity := range entitiesForPath[path] {
+					isChanged[entity.id] = true
+				}
+			}
+			timer = time.After(quietPeriod)
+		case <-timer:
+			timer = nil
+			isKnown := make(map[string]bool, len(entities))
+			for _, entity := range entities {
+				isKnown[entity.id] = true
+			}
+			if currentEntities := rescan(); currentEntities != nil {
+				entities = currentEntities
+			}
+
+			var changedEntities []*Entity
+			for _, entity := range entities {
+				if isChanged[entity.id] || !isKnown[entity.id] {
+					changedEntities = append(changedEntities, entity)
+				}
+			}
+			isChanged = make(map[string]bool)
+
+			//watch the paths of the current entities before calling the
+			//handler, so that changes made in the meantime are not missed
+			paths, entitiesForPath = watchPathsOf(entities)
+			err := watcher.SetPaths(paths)
+			if err != nil {
+				return err
+			}
+			handler(changedEntities)
+		}
+	}
+}
+
+// watchPathsOf collects the paths that the given entities want to be watched.
+func watchPathsOf(entities []*Entity) (paths []string, entitiesForPath map[string][]*Entity) {
+	entitiesForPath = make(map[string][]*Entity)
+	for _, entity := range entities {
+		for _, path := range entity.watchPaths {
+			path = filepath.Clean(path)
+			if len(entitiesForPath[path]) == 0 {
+				paths = append(paths, path)
+			}
+			entitiesForPath[path] = append(entitiesForPath[path], entity)
+		}
+	}
+	return paths, entitiesForPath
+}
+
+// HasWatchPaths returns whether the entity declared paths that `holo watch`
+// can watch for changes to this entity.
+func (e *Entity) HasWatchPaths() bool {
+	return len(e.watchPaths) > 0
+}
+
+// EndSessions ends the sessions of all plugins of the given entities, so that
+// they are started again with the current environment (in particular,
+// $HOLO_JOURNAL_DIR) on their next use.
+func EndSessions(entities []*Entity) {
+	isEnded := make(map[*Plugin]bool)
+	for _, entity := range entities {
+		if !isEnded[entity.plugin] {
+			entity.plugin.EndSession()
+			isEnded[entity.plugin] = true
+		}
+	}
+}
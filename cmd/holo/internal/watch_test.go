@@ -0,0 +1,180 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package impl
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func expectNextChange(t *testing.T, w *Watcher, expected ...string) {
+	t.Helper()
+	type result struct {
+		paths []string
+		err   error
+	}
+	results := make(chan result, 1)
+	go func() {
+		paths, err := w.Next()
+		results <- result{paths, err}
+	}()
+	select {
+	case r := <-results:
+		if r.err != nil {
+			t.Fatal(r.err.Error())
+		}
+		if !reflect.DeepEqual(r.paths, expected) {
+			t.Errorf("expected changes to %v, got %v", expected, r.paths)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out while waiting for changes to %v", expected)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	pathA := filepath.Join(dir, "a")
+	pathB := filepath.Join(dir, "sub/b")
+	w, err := NewWatcher([]string{pathA, pathB})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer w.Close()
+
+	//changes to other files in the watched directory are ignored
+	must(t, os.WriteFile(filepath.Join(dir, "c"), []byte("c"), 0644))
+	must(t, os.WriteFile(pathA, []byte("a"), 0644))
+	expectNextChange(t, w, pathA)
+
+	//creating a missing directory affects the paths below it...
+	must(t, os.Mkdir(filepath.Join(dir, "sub"), 0755))
+	expectNextChange(t, w, pathB)
+	//...and from then on, the directory itself is watched
+	must(t, os.WriteFile(pathB, []byte("b"), 0644))
+	expectNextChange(t, w, pathB)
+
+	//files that are replaced by renaming are still watched
+	must(t, os.WriteFile(pathA+".new", []byte("a2"), 0644))
+	must(t, os.Rename(pathA+".new", pathA))
+	expectNextChange(t, w, pathA)
+}
+
+// watchTestPlugin is a plugin for API version 4 that reports one entity for
+// each of its resource files. Like holo-files, it scans only once per session.
+const watchTestPlugin = `#!/bin/sh
+out=""
+for path in "$HOLO_RESOURCE_DIR"/*; do
+  [ -e "$path" ] || continue
+  out="${out}ENTITY: test:${path##*/}
+WATCH: $HOLO_ROOT_DIR/etc/${path##*/}
+"
+done
+while read -r header; do
+  head -c "${header#* }" > /dev/null
+  printf 'stdout %d\n%s' "$(printf '%s' "$out" | wc -c)" "$out"
+  printf 'status 1\n0'
+done
+`
+
+func TestWatchEntitiesWithAddedResource(t *testing.T) {
+	defer func(dir string) { rootDirectory = dir }(rootDirectory)
+	rootDirectory = t.TempDir()
+	for _, dir := range []string{"etc", "usr/share/holo/generators", "usr/share/holo/test"} {
+		must(t, os.MkdirAll(filepath.Join(rootDirectory, dir), 0755))
+	}
+	resourcePath := func(name string) string { return filepath.Join(rootDirectory, "usr/share/holo/test", name) }
+	targetPath := func(name string) string { return filepath.Join(rootDirectory, "etc", name) }
+	must(t, os.WriteFile(resourcePath("a"), nil, 0644))
+	executablePath := filepath.Join(t.TempDir(), "holo-test")
+	must(t, os.WriteFile(executablePath, []byte(watchTestPlugin), 0755))
+
+	WithCacheDirectory(func() int {
+		p := &Plugin{id: "test", executablePath: executablePath, apiVersion: 4}
+		p.UseVirtualResourceRoot()
+		defer p.EndSession()
+		plugins := []*Plugin{p}
+
+		entities := Rescan(plugins)
+		if actual := entityIDs(entities); actual != "test:a" {
+			t.Fatalf("expected initial scan to find test:a, got %q", actual)
+		}
+
+		stop := make(chan struct{})
+		calls := make(chan string)
+		done := make(chan error)
+		go func() {
+			done <- WatchEntities(entities, 10*time.Millisecond, stop,
+				func() []*Entity { return Rescan(plugins) },
+				func(changed []*Entity) { calls <- entityIDs(changed) },
+			)
+		}()
+		expectCall := func(expected string) {
+			t.Helper()
+			select {
+			case actual := <-calls:
+				if actual != expected {
+					t.Errorf("expected handler to be called with %q, got %q", expected, actual)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timed out while waiting for handler to be called with %q", expected)
+			}
+		}
+
+		//WatchEntities starts watching asynchronously, so poke the existing
+		//entity until it is picked up
+		for ready := false; !ready; {
+			must(t, os.WriteFile(targetPath("a"), nil, 0644))
+			select {
+			case actual := <-calls:
+				if actual != "test:a" {
+					t.Fatalf("expected handler to be called with \"test:a\", got %q", actual)
+				}
+				ready = true
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+
+		//a resource file added while watching is found by the next scan, so
+		//its entity is checked as well...
+		must(t, os.WriteFile(resourcePath("b"), nil, 0644))
+		must(t, os.WriteFile(targetPath("a"), nil, 0644))
+		expectCall("test:a test:b")
+		//...and its paths are watched from then on
+		must(t, os.WriteFile(targetPath("b"), nil, 0644))
+		expectCall("test:b")
+
+		close(stop)
+		if err := <-done; err != nil {
+			t.Error(err.Error())
+		}
+		return 0
+	})
+}
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+}
@@ -21,10 +21,16 @@
 package entrypoint
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	impl "github.com/holocm/holo/cmd/holo/internal"
 )
@@ -36,15 +42,26 @@ const (
 	optionApplyForce = iota
 	optionScanShort
 	optionScanPorcelain
+	optionJSON
+	optionApplyDryRun
+	optionSelectorsExpand
+	optionWatchReport
+	optionWatchApply
+	optionLogAll
+	optionDiffStat
+	optionDiffNameOnly
+	optionApplyInteractive
 )
 
-// Selector represents a command-line argument that selects entities. The Used
-// field tracks whether entities match this selector (to report unrecognized
-// selectors).
-type Selector struct {
-	String string
-	Used   bool
-}
+// watchQuietPeriod is how long `holo watch` waits for further changes before
+// it checks the changed entities.
+const watchQuietPeriod = time.Second
+
+// pluginReports describes the configured plugins for `holo plugins`.
+var pluginReports []impl.PluginReport
+
+// selectorAliases contains the aliases from holorc for `holo selectors`.
+var selectorAliases map[string][]string
 
 // Main is the main entry point, but returns the exit code rather than
 // calling os.Exit().  This distinction is useful for monobinary and
@@ -53,123 +70,241 @@ func Main() (exitCode int) {
 	//a command word must be given as first argument
 	if len(os.Args) < 2 {
 		commandHelp(os.Stderr)
-		return 2
+		return impl.ExitUsage
 	}
 
 	//check that it is a known command word
-	var command func([]*impl.Entity, map[int]bool) int
-	knownOpts := make(map[string]int)
-	switch os.Args[1] {
-	case "apply":
-		command = commandApply
-		knownOpts = map[string]int{"-f": optionApplyForce, "--force": optionApplyForce}
-	case "diff":
-		command = commandDiff
-	case "scan":
-		command = commandScan
-		knownOpts = map[string]int{
-			"-s": optionScanShort, "--short": optionScanShort,
-			"-p": optionScanPorcelain, "--porcelain": optionScanPorcelain,
-		}
-	case "selectors":
-		command = commandSelectors
-		if len(os.Args) > 2 {
-			//`holo selectors` does not accept selectors as arguments
-			commandHelp(os.Stderr)
-			return 2
-		}
-	case "version", "--version":
-		fmt.Println(version)
-		return 0
-	case "help", "--help":
-		commandHelp(os.Stdout)
-		return 0
-	default:
+	cmd := findCommand(os.Args[1])
+	if cmd == nil {
 		commandHelp(os.Stderr)
-		return 2
+		return impl.ExitUsage
+	}
+	if cmd.standalone != nil {
+		return cmd.standalone(os.Args[2:])
 	}
 
+	//the --color option (which is accepted by all subcommands) is evaluated
+	//first since it affects all output, including errors about other options;
+	//likewise, --root must be evaluated before holorc is read
+	args := os.Args[2:]
+	for _, earlyOpt := range []*optionDefinition{colorOption, rootOption} {
+		for idx := range args {
+			opt, value, _, err := matchOption([]*optionDefinition{earlyOpt}, args, idx)
+			if opt == nil {
+				continue
+			}
+			if err != nil {
+				impl.Errorf(impl.Stderr, "missing value for %s", opt.longName())
+				return impl.ExitUsage
+			}
+			err = opt.parse(nil, value)
+			if err != nil {
+				impl.Errorf(impl.Stderr, "invalid value for %s: %s", opt.longName(), err.Error())
+				return impl.ExitUsage
+			}
+		}
+	}
+
+	//on SIGINT or SIGTERM, finish the current entity and skip all others
+	//(instead of dying in the middle of an entity and without cleaning up)
+	defer impl.HandleInterrupts()()
+
 	return impl.WithCacheDirectory(func() (exitCode int) {
+		//`holo watch` stops when interrupted, which is not a failure
+		defer func() {
+			if impl.Interrupted() && os.Args[1] != "watch" {
+				exitCode = impl.ExitInterrupted
+			}
+		}()
+
 		//load configuration
 		config := impl.ReadConfiguration()
 		if config == nil {
 			//some fatal error occurred - it was already reported, so just exit
-			return 255
+			return impl.ExitFatal
+		}
+
+		//collect the facts that are exported to generators and plugins
+		err := impl.InitFacts(config)
+		if err != nil {
+			impl.Errorf(impl.Stderr, "cannot collect facts: %s", err.Error())
+			return impl.ExitFatal
 		}
 
 		//parse command line
-		options := make(map[int]bool)
-		selectors := make([]*Selector, 0, len(os.Args)-2)
-
-		args := os.Args[2:]
-		for _, arg := range args {
-			//either it's a known option for this subcommand...
-			if value, ok := knownOpts[arg]; ok {
-				options[value] = true
+		line := commandLine{
+			options:  make(map[int]bool),
+			timeouts: config.Timeouts,
+			diffFrom: "provisioned",
+			diffTo:   "current",
+		}
+		allOptions := cmd.allOptions()
+		for idx := 0; idx < len(args); idx++ {
+			arg := args[idx]
+			//either it's an option that this subcommand accepts...
+			opt, value, consumed, err := matchOption(allOptions, args, idx)
+			if err != nil {
+				impl.Errorf(impl.Stderr, "missing value for %s", opt.longName())
+				return impl.ExitUsage
+			}
+			if opt != nil {
+				idx += consumed
+				if opt.argument == "" {
+					line.options[opt.flag] = true
+					continue
+				}
+				err := opt.parse(&line, value)
+				if err != nil {
+					impl.Errorf(impl.Stderr, "invalid value for %s: %s", opt.longName(), err.Error())
+					return impl.ExitUsage
+				}
 				continue
 			}
 			//...or it must be a selector
-			selectors = append(selectors, &Selector{String: arg, Used: false})
+			selector, err := impl.NewSelector(arg, false)
+			if err != nil {
+				impl.Errorf(impl.Stderr, err.Error())
+				return impl.ExitUsage
+			}
+			line.selectors = append(line.selectors, selector)
 		}
+		options := line.options
 
-		//run generators before scan phase
-		err := impl.RunAllGenerators()
-		if err == nil {
-			err = impl.FinalizeVirtualResourceRoot()
-		}
+		//replace aliases from holorc by the selectors that they stand for
+		selectorAliases = config.Aliases
+		selectors, err := impl.ExpandAliases(line.selectors, config.Aliases)
 		if err != nil {
 			impl.Errorf(impl.Stderr, err.Error())
-			impl.Stderr.EndParagraph()
-			return 1
+			return impl.ExitFatal
 		}
-		for _, plugin := range config.Plugins {
-			plugin.UseVirtualResourceRoot()
+		line.selectors = selectors
+		line.plugins = config.Plugins
+
+		if options[optionDiffStat] && options[optionDiffNameOnly] {
+			impl.Errorf(impl.Stderr, "--stat and --name-only cannot be used together")
+			return impl.ExitUsage
 		}
+		if options[optionApplyInteractive] && (options[optionApplyDryRun] || options[optionJSON]) {
+			impl.Errorf(impl.Stderr, "--interactive cannot be used together with --dry-run or --json")
+			return impl.ExitUsage
+		}
+
+		//timeouts given on the command line override those from holorc
+		impl.SetTimeouts(line.timeouts)
 
-		//ask all plugins to scan for entities
+		//`holo selectors` with selectors shows what these expand to
+		if os.Args[1] == "selectors" && len(selectors) > 0 {
+			options[optionSelectorsExpand] = true
+		}
+
+		//with machine-readable output, stdout is reserved for the JSON
+		//documents, so all human-readable output goes to stderr instead
+		if options[optionJSON] {
+			impl.RedirectStdoutToStderr()
+		}
+
+		//ensure that we're the only Holo instance that modifies the system
+		//(read-only operations can run in parallel, but not while the system
+		//is being modified)
+		isModifying := os.Args[1] == "rollback" || (os.Args[1] == "apply" && !options[optionApplyDryRun])
+		lockMode := impl.SharedLock
+		if isModifying {
+			lockMode = impl.ExclusiveLock
+		}
+		if !impl.AcquireLockfile(lockMode, line.lockTimeout) {
+			return impl.ExitFatal
+		}
+		defer impl.ReleaseLockfile()
+
+		//plugin sessions must be ended before the cache directory is removed
+		defer func() {
+			for _, plugin := range config.Plugins {
+				plugin.EndSession()
+			}
+		}()
+
+		//`holo apply` records the prior states of all entities that it
+		//changes in a journal (this must be set up before plugins are started)
+		if os.Args[1] == "apply" && !options[optionApplyDryRun] {
+			err := impl.StartJournal()
+			if err != nil {
+				impl.Errorf(impl.Stderr, "cannot create journal: %s", err.Error())
+				return impl.ExitFatal
+			}
+			defer impl.FinishJournal()
+		}
+
+		//find the entities to work on: for `holo rollback`, they are listed in
+		//the journal of the run that is rolled back (which can be selected by
+		//the first argument); for `holo log`, they are listed in the history;
+		//`holo facts` and `holo plugins` do not need any; otherwise, all
+		//plugins scan for entities
 		var entities []*impl.Entity
-		for _, plugin := range config.Plugins {
-			pluginEntities := plugin.Scan()
-			if pluginEntities == nil {
+		if os.Args[1] == "facts" {
+			//nothing to do
+		} else if os.Args[1] == "plugins" {
+			pluginReports = config.PluginReports()
+		} else if os.Args[1] == "log" {
+			entries, err := impl.ReadHistory()
+			if err != nil {
+				impl.Errorf(impl.Stderr, err.Error())
+				return impl.ExitFatal
+			}
+			entities = impl.HistoryEntities(entries, config.Plugins)
+		} else if os.Args[1] == "rollback" {
+			runID := ""
+			if len(selectors) > 0 && impl.IsJournalRun(selectors[0].Argument) {
+				runID = selectors[0].Argument
+				selectors = selectors[1:]
+			}
+			var err error
+			entities, err = impl.OpenJournal(runID, config.Plugins)
+			if err != nil {
+				impl.Errorf(impl.Stderr, err.Error())
+				return impl.ExitFatal
+			}
+		} else {
+			//run generators before scan phase
+			err := impl.RunAllGenerators()
+			if err == nil {
+				err = impl.FinalizeVirtualResourceRoot()
+			}
+			if err != nil {
+				impl.Errorf(impl.Stderr, err.Error())
+				impl.Stderr.EndParagraph()
+				return impl.ExitErrors
+			}
+			for _, plugin := range config.Plugins {
+				plugin.UseVirtualResourceRoot()
+			}
+
+			//ask all plugins to scan for entities
+			entities = impl.ScanAll(config.Plugins)
+			if entities == nil {
 				//some fatal error occurred - it was already reported, so just exit
-				return 255
+				return impl.ExitFatal
 			}
-			entities = append(entities, pluginEntities...)
-			impl.Stdout.EndParagraph()
 		}
 
 		//if there are selectors, check which entities have been selected by them
 		if len(selectors) > 0 {
-			selectedEntities := make([]*impl.Entity, 0, len(entities))
-			for _, entity := range entities {
-				isEntitySelected := false
-				isMatchingSelector := entity.AllMatchingSelectors()
-				for _, selector := range selectors {
-					if isMatchingSelector[selector.String] {
-						isEntitySelected = true
-						selector.Used = true
-						//NOTE: don't break from the selectors loop; we want to
-						//look at every selector because this loop also verifies
-						//that selectors are valid
-					}
-				}
-				if isEntitySelected {
-					selectedEntities = append(selectedEntities, entity)
-				}
-			}
-			entities = selectedEntities
+			entities = impl.SelectEntities(entities, selectors)
 		}
 
 		//were there unrecognized selectors?
 		hasUnrecognizedArgs := false
 		for _, selector := range selectors {
 			if !selector.Used {
-				fmt.Fprintf(os.Stderr, "Unrecognized argument: %s\n", selector.String)
+				if selector.Alias == "" {
+					fmt.Fprintf(os.Stderr, "Unrecognized argument: %s\n", selector.Argument)
+				} else {
+					fmt.Fprintf(os.Stderr, "Unrecognized argument: %s (in alias %s)\n", selector.Argument, selector.Alias)
+				}
 				hasUnrecognizedArgs = true
 			}
 		}
 		if hasUnrecognizedArgs {
-			return 255
+			return impl.ExitFatal
 		}
 
 		//build a lookup hash for all known entities (for argument parsing)
@@ -179,46 +314,401 @@ func Main() (exitCode int) {
 		}
 
 		//execute command
-		return command(entities, options)
+		return cmd.run(entities, &line)
 
 	}) //end of WithCacheDirectory
 }
 
-func commandHelp(w io.Writer) {
-	program := os.Args[0]
-	fmt.Fprintf(w, "Usage: %s apply [-f|--force] [selector ...]\n", program)
-	fmt.Fprintf(w, "   or: %s diff [selector ...]\n", program)
-	fmt.Fprintf(w, "   or: %s scan [-s|--short|-p|--porcelain] [selector ...]\n", program)
-	fmt.Fprintf(w, "   or: %s selectors\n", program)
-	fmt.Fprintf(w, "   or: %s version\n", program)
-	fmt.Fprintf(w, "   or: %s help\n", program)
-	fmt.Fprintf(w, "\nSee `man 8 holo` for details.\n")
-}
+func commandApply(entities []*impl.Entity, cmdLine *commandLine) (exitCode int) {
+	//entities must be applied after the entities that they require
+	entities, err := impl.OrderByDependencies(entities)
+	if err != nil {
+		impl.Errorf(impl.Stderr, err.Error())
+		return impl.ExitFatal
+	}
+
+	isDryRun := cmdLine.options[optionApplyDryRun]
+	withForce := cmdLine.options[optionApplyForce]
+	isJSON := cmdLine.options[optionJSON]
+	if !isDryRun {
+		impl.StartHistory(os.Args[1:])
+	}
+	var prompt *impl.PromptReader
+	decisions := make(impl.ReviewSummary)
+	if cmdLine.options[optionApplyInteractive] {
+		prompt = impl.NewPromptReader(os.Stdin)
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	isFailed := make(map[string]bool) //entity ID -> whether it was not applied (see ApplyOutcome.BlocksDependents)
+	for idx, entity := range entities {
+		if impl.Interrupted() {
+			impl.ReportNotProcessed(entities[idx:])
+			break
+		}
+		var (
+			outcome impl.ApplyOutcome
+			err     error
+		)
+		failedRequirement := ""
+		for _, requiredID := range entity.Requires() {
+			if isFailed[requiredID] {
+				failedRequirement = requiredID
+				break
+			}
+		}
+
+		//in interactive mode, ask the user before applying
+		decision := impl.ReviewApply
+		if prompt != nil && failedRequirement == "" {
+			decision = entity.Review(prompt)
+			if decision == impl.ReviewQuit {
+				if impl.Interrupted() {
+					impl.ReportNotProcessed(entities[idx:])
+				}
+				decisions[impl.ReviewQuit] += len(entities) - idx
+				break
+			}
+			decisions[decision]++
+		}
 
-func commandApply(entities []*impl.Entity, options map[int]bool) (exitCode int) {
-	//ensure that we're the only Holo instance
-	if !impl.AcquireLockfile() {
-		return 255
+		switch {
+		case failedRequirement != "":
+			outcome, err = impl.ApplySkipped, entity.Skip(failedRequirement)
+		case decision == impl.ReviewSkip:
+			outcome, err = entity.Decline()
+		case isDryRun:
+			outcome, err = entity.Plan(withForce)
+		default:
+			outcome, err = entity.Apply(withForce || decision == impl.ReviewForceApply)
+		}
+		if outcome.BlocksDependents() {
+			isFailed[entity.EntityID()] = true
+		}
+		if isJSON {
+			encoder.Encode(entity.NewApplyReport(outcome, err))
+		}
+
+		os.Stderr.Sync()
+		impl.Stdout.EndParagraph()
+		os.Stdout.Sync()
 	}
-	defer impl.ReleaseLockfile()
+	recordHistory(entities)
 
-	withForce := options[optionApplyForce]
-	for _, entity := range entities {
-		entity.Apply(withForce)
+	if line := decisions.String(); line != "" {
+		fmt.Fprintln(impl.Stdout, line)
+	}
+	summary := impl.NewApplySummary(entities, isDryRun)
+	if line := summary.String(); line != "" {
+		fmt.Fprintln(impl.Stdout, line)
+	}
+	impl.Stdout.EndParagraph()
+	return summary.ExitCode()
+}
+
+func commandRollback(entities []*impl.Entity, cmdLine *commandLine) (exitCode int) {
+	impl.StartHistory(os.Args[1:])
+	//undo changes in the reverse order in which they were made
+	for idx := len(entities) - 1; idx >= 0; idx-- {
+		if impl.Interrupted() {
+			impl.ReportNotProcessed(entities[:idx+1])
+			break
+		}
+		entities[idx].Rollback()
 
 		os.Stderr.Sync()
 		impl.Stdout.EndParagraph()
 		os.Stdout.Sync()
 	}
+	recordHistory(entities)
+
+	summary := impl.NewApplySummary(entities, false)
+	if line := summary.String(); line != "" {
+		fmt.Fprintln(impl.Stdout, line)
+		impl.Stdout.EndParagraph()
+	}
+	return summary.ExitCode()
+}
+
+func commandCheck(entities []*impl.Entity, cmdLine *commandLine) (exitCode int) {
+	statuses := make([]impl.CheckStatus, 0, len(entities))
+	checkable := checkableEntities(entities, "check")
+	for idx, entity := range checkable {
+		if impl.Interrupted() {
+			impl.ReportNotProcessed(checkable[idx:])
+			break
+		}
+		statuses = append(statuses, checkEntity(entity))
+	}
+	return impl.CheckExitCode(statuses)
+}
+
+func commandExplain(entities []*impl.Entity, cmdLine *commandLine) (exitCode int) {
+	explainable := entitiesSupporting(entities, "explain", "explain")
+	for idx, entity := range explainable {
+		if impl.Interrupted() {
+			impl.ReportNotProcessed(explainable[idx:])
+			break
+		}
+		err := entity.Explain()
+		if err != nil {
+			impl.Errorf(impl.Stderr, "cannot explain %s: %s", entity.EntityID(), err.Error())
+			exitCode = impl.ExitErrors
+		}
+		impl.Stdout.EndParagraph()
+	}
+	return exitCode
+}
+
+// checkableEntities returns those entities whose plugins support the "check"
+// operation. For the other plugins, a warning is printed (once per plugin).
+// Entities that are not checked are not counted against the exit code, but
+// the user shall know that they were not checked.
+func checkableEntities(entities []*impl.Entity, verb string) []*impl.Entity {
+	return entitiesSupporting(entities, "check", verb)
+}
+
+// entitiesSupporting returns those entities whose plugins support the given
+// optional operation. For the other plugins, a warning is printed (once per
+// plugin).
+func entitiesSupporting(entities []*impl.Entity, operation, verb string) []*impl.Entity {
+	result := make([]*impl.Entity, 0, len(entities))
+	isUnsupportedPlugin := make(map[string]bool)
+	for _, entity := range entities {
+		if entity.SupportsOperation(operation) {
+			result = append(result, entity)
+			continue
+		}
+		pluginID := entity.PluginID()
+		if !isUnsupportedPlugin[pluginID] {
+			impl.Warnf(os.Stderr, "Cannot %s entities of plugin %s: plugin does not support the %q operation", verb, pluginID, operation)
+			isUnsupportedPlugin[pluginID] = true
+		}
+	}
+	return result
+}
+
+// checkEntity checks a single entity for `holo check` and `holo watch`, and
+// prints a status line if the entity is not in sync. The output is meant to
+// be concise (e.g. for alerts from a monitoring system), so it does not use
+// the paragraph formatting of impl.Stdout.
+func checkEntity(entity *impl.Entity) impl.CheckStatus {
+	//plugin output (usually error messages) is shown below the status line
+	var output bytes.Buffer
+	status, err := entity.Check(&output)
+	if status != impl.CheckInSync {
+		fmt.Printf("%-9s %s\n", status.String(), entity.EntityID())
+		os.Stdout.Sync()
+	}
+	os.Stderr.Write(output.Bytes())
+	if err != nil {
+		impl.Errorf(os.Stderr, err.Error())
+	}
+	os.Stderr.Sync()
+	return status
+}
+
+func commandWatch(entities []*impl.Entity, cmdLine *commandLine) (exitCode int) {
+	//changes can only be detected for entities whose plugin can check them,
+	//and that declared paths to watch in their scan report
+	watchedEntities := watchableEntities(checkableEntities(entities, "watch"))
+	if len(watchedEntities) == 0 {
+		impl.Errorf(os.Stderr, "no entities to watch")
+		return impl.ExitFatal
+	}
+
+	//the lock is only held while changes are being handled, so that `holo
+	//apply` can run in the meantime
+	impl.ReleaseLockfile()
+	withApply := cmdLine.options[optionWatchApply]
+	lockMode := impl.SharedLock
+	if withApply {
+		lockMode = impl.ExclusiveLock
+	}
+
+	fmt.Fprintf(os.Stderr, "Watching %d entities for changes...\n", len(watchedEntities))
+	//plugins need to scan again before each round of checks to notice added or
+	//removed resource files (plugin sessions would otherwise keep reporting the
+	//entities from their first scan)
+	rescan := func() []*impl.Entity {
+		if !impl.AcquireLockfile(impl.SharedLock, impl.WaitForever) {
+			return nil
+		}
+		defer impl.ReleaseLockfile()
+		entities := impl.Rescan(cmdLine.plugins)
+		if entities == nil {
+			return nil
+		}
+		watchedEntities = watchableEntities(impl.SelectEntities(entities, cmdLine.selectors))
+		return watchedEntities
+	}
 
+	err := impl.WatchEntities(watchedEntities, watchQuietPeriod, impl.Interrupts(), rescan, func(changedEntities []*impl.Entity) {
+		if !impl.AcquireLockfile(lockMode, impl.WaitForever) {
+			return
+		}
+		defer impl.ReleaseLockfile()
+
+		//changes made by `holo apply` do not count since the entities are
+		//in sync afterwards
+		var toApply []*impl.Entity
+		for _, entity := range changedEntities {
+			status := checkEntity(entity)
+			if status == impl.CheckInSync || status == impl.CheckFailed {
+				continue
+			}
+			if cmdLine.watchHook != "" {
+				runWatchHook(cmdLine.watchHook, entity, status)
+			}
+			//drifted entities would require --force, so they are only reported
+			if status != impl.CheckDrifted {
+				toApply = append(toApply, entity)
+			}
+		}
+		if !withApply || len(toApply) == 0 {
+			return
+		}
+
+		//re-apply like `holo apply` would (plugins need to be restarted to pick
+		//up the journal directory for this run)
+		impl.EndSessions(watchedEntities)
+		err := impl.StartJournal()
+		if err != nil {
+			impl.Errorf(impl.Stderr, "cannot create journal: %s", err.Error())
+			return
+		}
+		commandApply(toApply, &commandLine{options: make(map[int]bool)})
+		impl.FinishJournal()
+	})
+	if err != nil {
+		impl.Errorf(os.Stderr, "cannot watch for changes: %s", err.Error())
+		return impl.ExitErrors
+	}
+	return impl.ExitSuccess
+}
+
+// watchableEntities returns those entities whose changes can be detected by
+// `holo watch`, i.e. those whose plugin can check them, and that declared
+// paths to watch in their scan report. Unlike checkableEntities(), this does
+// not warn about plugins that cannot check entities.
+func watchableEntities(entities []*impl.Entity) []*impl.Entity {
+	var result []*impl.Entity
+	for _, entity := range entities {
+		if entity.SupportsOperation("check") && entity.HasWatchPaths() {
+			result = append(result, entity)
+		}
+	}
+	return result
+}
+
+// runWatchHook runs the command given with `holo watch --hook=COMMAND` for an
+// entity that is not in sync.
+func runWatchHook(hook string, entity *impl.Entity, status impl.CheckStatus) {
+	cmd := exec.Command("/bin/sh", "-c", hook)
+	cmd.Env = append(os.Environ(),
+		"HOLO_ENTITY_ID="+entity.EntityID(),
+		"HOLO_CHECK_STATUS="+status.String(),
+	)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	if err != nil {
+		impl.Errorf(os.Stderr, "hook failed for %s: %s", entity.EntityID(), err.Error())
+	}
+}
+
+// recordHistory appends the current run of `holo apply` or `holo rollback` to
+// the history file (see `holo log`).
+func recordHistory(entities []*impl.Entity) {
+	err := impl.FinishHistory(entities)
+	if err != nil {
+		impl.Errorf(impl.Stderr, "cannot record history: %s", err.Error())
+		impl.Stderr.EndParagraph()
+	}
+}
+
+func commandLog(entities []*impl.Entity, cmdLine *commandLine) (exitCode int) {
+	entries, err := impl.ReadHistory()
+	if err != nil {
+		impl.Errorf(impl.Stderr, err.Error())
+		return impl.ExitFatal
+	}
+	isSelected := make(map[string]bool, len(entities))
+	for _, entity := range entities {
+		isSelected[entity.EntityID()] = true
+	}
+
+	//show the most recent runs first, and only those that concern the
+	//selected entities
+	showAll := cmdLine.options[optionLogAll]
+	isJSON := cmdLine.options[optionJSON]
+	encoder := json.NewEncoder(os.Stdout)
+	for idx := len(entries) - 1; idx >= 0; idx-- {
+		entry := entries[idx]
+		var shownEntities []impl.HistoryEntity
+		for _, he := range entry.Entities {
+			if isSelected[he.ID] && (showAll || he.Outcome != impl.ApplyUnchanged.String()) {
+				shownEntities = append(shownEntities, he)
+			}
+		}
+		if len(shownEntities) == 0 {
+			continue
+		}
+		entry.Entities = shownEntities
+
+		if isJSON {
+			encoder.Encode(entry)
+			continue
+		}
+		line := fmt.Sprintf("%s: holo %s", entry.Time.Format("2006-01-02 15:04:05 MST"), strings.Join(entry.Command, " "))
+		if entry.Journal != "" {
+			line += fmt.Sprintf(" (journal %s)", entry.Journal)
+		}
+		fmt.Fprintln(impl.Stdout, line)
+		for _, he := range entry.Entities {
+			//this looks like the entity report in `holo apply`, e.g. "Scrubbing
+			//file:/etc/foo.conf (target was deleted): changed"
+			verb := he.ActionVerb
+			if verb == "" {
+				verb = "Working on"
+			}
+			line := fmt.Sprintf("    %s %s", verb, he.ID)
+			if he.ActionReason != "" {
+				line += fmt.Sprintf(" (%s)", he.ActionReason)
+			}
+			line += ": " + he.Outcome
+			if he.StateBefore != "" || he.StateAfter != "" {
+				line += fmt.Sprintf(", %s -> %s", shortHash(he.StateBefore), shortHash(he.StateAfter))
+			}
+			if he.Error != "" {
+				line += ": " + he.Error
+			}
+			fmt.Fprintln(impl.Stdout, line)
+		}
+		impl.Stdout.EndParagraph()
+	}
 	return 0
 }
 
-func commandScan(entities []*impl.Entity, options map[int]bool) (exitCode int) {
-	isPorcelain := options[optionScanPorcelain]
-	isShort := options[optionScanShort]
+// shortHash abbreviates a hash from the history for display.
+func shortHash(hash string) string {
+	if hash == "" {
+		return "unknown"
+	}
+	if strings.HasPrefix(hash, "sha256:") && len(hash) > 19 {
+		return hash[:19]
+	}
+	return hash
+}
+
+func commandScan(entities []*impl.Entity, cmdLine *commandLine) (exitCode int) {
+	isPorcelain := cmdLine.options[optionScanPorcelain]
+	isShort := cmdLine.options[optionScanShort]
+	isJSON := cmdLine.options[optionJSON]
+	encoder := json.NewEncoder(os.Stdout)
 	for _, entity := range entities {
 		switch {
+		case isJSON:
+			encoder.Encode(entity.Report())
 		case isPorcelain:
 			entity.PrintScanReport()
 		case isShort:
@@ -231,36 +721,230 @@ func commandScan(entities []*impl.Entity, options map[int]bool) (exitCode int) {
 	return 0
 }
 
-func commandSelectors(entities []*impl.Entity, options map[int]bool) (exitCode int) {
-	isSelector := make(map[string]bool)
+func commandSelectors(entities []*impl.Entity, cmdLine *commandLine) (exitCode int) {
+	//when selectors were given, show the entities that they expand to
+	if cmdLine.options[optionSelectorsExpand] {
+		isJSON := cmdLine.options[optionJSON]
+		encoder := json.NewEncoder(os.Stdout)
+		for _, entity := range entities {
+			if isJSON {
+				encoder.Encode(struct {
+					EntityID string `json:"entity"`
+				}{entity.EntityID()})
+			} else {
+				fmt.Println(entity.EntityID())
+			}
+		}
+		return 0
+	}
+
+	entityIDsForSelector := make(map[string][]string)
 	for _, entity := range entities {
 		for selector := range entity.AllMatchingSelectors() {
-			isSelector[selector] = true
+			entityIDsForSelector[selector] = append(entityIDsForSelector[selector], entity.EntityID())
+		}
+	}
+	//an alias is valid if each of its selectors matches some entity
+	for name := range selectorAliases {
+		selectors, err := impl.ExpandAliases([]*impl.Selector{{String: name}}, selectorAliases)
+		if err != nil {
+			continue
+		}
+		var entityIDs []string
+		for _, entity := range impl.SelectEntities(entities, selectors) {
+			entityIDs = append(entityIDs, entity.EntityID())
+		}
+		isValid := true
+		for _, selector := range selectors {
+			isValid = isValid && selector.Used
+		}
+		if isValid {
+			entityIDsForSelector[name] = entityIDs
 		}
 	}
-	allSelectors := make([]string, 0, len(isSelector))
-	for selector := range isSelector {
+
+	allSelectors := make([]string, 0, len(entityIDsForSelector))
+	for selector := range entityIDsForSelector {
 		allSelectors = append(allSelectors, selector)
 	}
 	sort.Strings(allSelectors)
+
+	isJSON := cmdLine.options[optionJSON]
+	encoder := json.NewEncoder(os.Stdout)
 	for _, selector := range allSelectors {
-		fmt.Println(selector)
+		if isJSON {
+			encoder.Encode(struct {
+				Selector  string   `json:"selector"`
+				EntityIDs []string `json:"entities"`
+			}{selector, entityIDsForSelector[selector]})
+		} else {
+			fmt.Println(selector)
+		}
 	}
 	return 0
 }
 
-func commandDiff(entities []*impl.Entity, options map[int]bool) (exitCode int) {
-	for _, entity := range entities {
-		output, err := entity.RenderDiff()
+func commandFacts(entities []*impl.Entity, cmdLine *commandLine) (exitCode int) {
+	facts := impl.CurrentFacts()
+	if cmdLine.options[optionJSON] {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(facts)
+		return 0
+	}
+	for _, line := range facts.Environ() {
+		fmt.Println(line)
+	}
+	return 0
+}
+
+func commandPlugins(entities []*impl.Entity, cmdLine *commandLine) (exitCode int) {
+	if cmdLine.options[optionJSON] {
+		encoder := json.NewEncoder(os.Stdout)
+		for _, report := range pluginReports {
+			encoder.Encode(report)
+		}
+		return 0
+	}
+
+	for _, report := range pluginReports {
+		fmt.Fprint(impl.Stdout, colorize(impl.Stdout, report.ID, "\x1B[1m"))
+		if report.Skipped {
+			fmt.Fprintf(impl.Stdout, " (skipped: %s)", report.SkipReason)
+		} else if report.Version != "" {
+			fmt.Fprintf(impl.Stdout, " %s", report.Version)
+		}
+		fmt.Fprintln(impl.Stdout)
+
+		lines := [][2]string{
+			{"description", report.Description},
+			{"executable", report.ExecutablePath},
+			{"conditions", strings.Join(report.Conditions, " ")},
+		}
+		if !report.Skipped {
+			lines = append(lines,
+				[2]string{"resources", report.ResourceDirectory},
+				[2]string{"state", report.StateDirectory},
+				[2]string{"environment", strings.Join(report.Environment, " ")},
+				[2]string{"api", fmt.Sprintf("versions %d-%d (using %d)",
+					report.MinAPIVersion, report.MaxAPIVersion, report.APIVersion)},
+				[2]string{"entities", strings.Join(report.EntityPrefixes, " ")},
+				[2]string{"operations", strings.Join(report.Operations, " ")},
+			)
+		}
+		for _, line := range lines {
+			if line[1] != "" {
+				fmt.Fprintf(impl.Stdout, "%12s %s\n", line[0], line[1])
+			}
+		}
+		impl.Stdout.EndParagraph()
+	}
+	return 0
+}
+
+func commandDiff(entities []*impl.Entity, cmdLine *commandLine) (exitCode int) {
+	exitCode = impl.ExitSuccess
+	var stats []diffStatLine
+	for idx, entity := range entities {
+		if impl.Interrupted() {
+			impl.ReportNotProcessed(entities[idx:])
+			break
+		}
+		diff, err := entity.Diff(cmdLine.diffFrom, cmdLine.diffTo)
 		if err != nil {
 			impl.Errorf(impl.Stderr, "cannot diff %s: %s", entity.EntityID(), err.Error())
+			exitCode = impl.ExitErrors
+		}
+		switch {
+		case diff == nil || len(diff.Output) == 0:
+			//nothing to show
+		case cmdLine.options[optionDiffNameOnly]:
+			fmt.Println(entity.EntityID())
+		case cmdLine.options[optionDiffStat]:
+			stats = append(stats, diffStatLine{entity.EntityID(), diff.Stat})
+		default:
+			os.Stdout.Write(diff.Output)
 		}
-		os.Stdout.Write(output)
 
 		os.Stderr.Sync()
 		impl.Stdout.EndParagraph()
 		os.Stdout.Sync()
 	}
 
-	return 0
+	if cmdLine.options[optionDiffStat] {
+		printDiffStat(os.Stdout, stats)
+	}
+	return exitCode
+}
+
+// diffStatLine is a line in the output of `holo diff --stat`.
+type diffStatLine struct {
+	entityID string
+	stat     impl.DiffStat
+}
+
+// diffStatWidth is the maximum width of the bar graph in `holo diff --stat`.
+const diffStatWidth = 40
+
+// printDiffStat prints the output of `holo diff --stat` in the same format as
+// `git diff --stat`.
+func printDiffStat(w io.Writer, lines []diffStatLine) {
+	if len(lines) == 0 {
+		return
+	}
+
+	nameWidth, maxChanges := 0, 0
+	var total impl.DiffStat
+	for _, line := range lines {
+		if len(line.entityID) > nameWidth {
+			nameWidth = len(line.entityID)
+		}
+		if changes := line.stat.Insertions + line.stat.Deletions; changes > maxChanges {
+			maxChanges = changes
+		}
+		total.Insertions += line.stat.Insertions
+		total.Deletions += line.stat.Deletions
+	}
+	countWidth := len(strconv.Itoa(maxChanges))
+
+	for _, line := range lines {
+		if line.stat.Binary {
+			fmt.Fprintf(w, " %-*s | Bin\n", nameWidth, line.entityID)
+			continue
+		}
+		//scale the bar graph down if necessary
+		plus, minus := line.stat.Insertions, line.stat.Deletions
+		if maxChanges > diffStatWidth {
+			plus = (plus*diffStatWidth + maxChanges - 1) / maxChanges
+			minus = (minus*diffStatWidth + maxChanges - 1) / maxChanges
+		}
+		fmt.Fprintf(w, " %-*s | %*d %s%s\n", nameWidth, line.entityID, countWidth,
+			line.stat.Insertions+line.stat.Deletions,
+			colorize(w, strings.Repeat("+", plus), "\x1B[32m"),
+			colorize(w, strings.Repeat("-", minus), "\x1B[31m"),
+		)
+	}
+
+	summary := fmt.Sprintf(" %d %s changed", len(lines), plural(len(lines), "entity", "entities"))
+	if total.Insertions > 0 || total.Deletions == 0 {
+		summary += fmt.Sprintf(", %d %s(+)", total.Insertions, plural(total.Insertions, "insertion", "insertions"))
+	}
+	if total.Deletions > 0 || total.Insertions == 0 {
+		summary += fmt.Sprintf(", %d %s(-)", total.Deletions, plural(total.Deletions, "deletion", "deletions"))
+	}
+	fmt.Fprintln(w, summary)
+}
+
+func colorize(w io.Writer, text, color string) string {
+	if text == "" || !impl.UseColors(w) {
+		return text
+	}
+	return color + text + "\x1B[0m"
+}
+
+func plural(count int, singular, plural string) string {
+	if count == 1 {
+		return singular
+	}
+	return plural
 }
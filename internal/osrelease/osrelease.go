@@ -0,0 +1,90 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+// Package osrelease parses the os-release(5) file that describes the
+// distribution that Holo is running on.
+package osrelease
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var escapeRx = regexp.MustCompile(`\\(.)`)
+
+// Read reads /etc/os-release below the given root directory, falling back to
+// /usr/lib/os-release if the former is not available, and returns the
+// variables defined therein.
+func Read(rootDir string) (map[string]string, error) {
+	bytes, err := os.ReadFile(filepath.Join(rootDir, "etc/os-release"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			bytes, err = os.ReadFile(filepath.Join(rootDir, "usr/lib/os-release"))
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Parse(string(bytes)), nil
+}
+
+// Parse parses the contents of an os-release(5) file.
+func Parse(contents string) map[string]string {
+	//parse os-release syntax (a harshly limited subset of shell script)
+	variables := make(map[string]string)
+	lines := strings.Split(contents, "\n")
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		//ignore comments
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		//line format is key=value
+		if !strings.Contains(line, "=") {
+			continue
+		}
+		split := strings.SplitN(line, "=", 2)
+		key, value := split[0], split[1]
+		//value may be enclosed in quotes
+		switch {
+		case strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\""):
+			value = strings.TrimPrefix(strings.TrimSuffix(value, "\""), "\"")
+		case strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'"):
+			value = strings.TrimPrefix(strings.TrimSuffix(value, "'"), "'")
+		}
+		//special characters may be escaped
+		value = escapeRx.ReplaceAllString(value, "$1")
+		//store assignment
+		variables[key] = value
+	}
+	return variables
+}
+
+// DistributionIDs returns the set of distribution IDs from the ID= (single
+// value) and ID_LIKE= (space-separated list) variables.
+func DistributionIDs(variables map[string]string) map[string]bool {
+	result := map[string]bool{variables["ID"]: true}
+	for _, id := range strings.Fields(variables["ID_LIKE"]) {
+		result[id] = true
+	}
+	return result
+}
@@ -0,0 +1,51 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package osrelease
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	variables := Parse(`# comment
+NAME="Foo Linux"
+ID=foo
+ID_LIKE='bar  baz'
+PRETTY_NAME="Foo \"Linux\""
+garbage
+`)
+	expected := map[string]string{
+		"NAME":        "Foo Linux",
+		"ID":          "foo",
+		"ID_LIKE":     "bar  baz",
+		"PRETTY_NAME": `Foo "Linux"`,
+	}
+	if !reflect.DeepEqual(variables, expected) {
+		t.Errorf("expected %#v, got %#v", expected, variables)
+	}
+
+	ids := DistributionIDs(variables)
+	expectedIDs := map[string]bool{"foo": true, "bar": true, "baz": true}
+	if !reflect.DeepEqual(ids, expectedIDs) {
+		t.Errorf("expected %#v, got %#v", expectedIDs, ids)
+	}
+}
@@ -0,0 +1,35 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+package pluginapi
+
+import "os"
+
+// IsTestMode returns whether the plugin is running in test mode (as indicated
+// by $HOLO_TEST_MODE). In test mode, $HOLO_ROOT_DIR stands in for the root
+// directory of the system under test, and plugins shall not run programs that
+// modify the system, but only report what they would have run.
+//
+// Note that $HOLO_ROOT_DIR alone does not imply test mode: It is also set when
+// Holo provisions an alternate root (e.g. a mounted disk image) with `holo
+// --root`, in which case plugins shall perform the actual operations on that
+// root.
+func IsTestMode() bool {
+	return os.Getenv("HOLO_TEST_MODE") != ""
+}
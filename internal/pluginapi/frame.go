@@ -0,0 +1,98 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package pluginapi
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Frame types used in the session protocol of plugin API version 4. See
+// holo-plugin-interface(7) for details.
+const (
+	FrameRequest = "request"
+	FrameStdout  = "stdout"
+	FrameStderr  = "stderr"
+	FrameMessage = "message"
+	FrameStatus  = "status"
+)
+
+// WriteFrame writes a single frame in the format "$TYPE $LENGTH\n$PAYLOAD".
+func WriteFrame(w io.Writer, frameType string, payload []byte) error {
+	_, err := fmt.Fprintf(w, "%s %d\n", frameType, len(payload))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(payload)
+	return err
+}
+
+// ReadFrame reads a single frame that was written by WriteFrame. If the
+// stream ends before the start of the next frame, io.EOF is returned.
+func ReadFrame(r *bufio.Reader) (frameType string, payload []byte, err error) {
+	header, err := r.ReadString('\n')
+	if err != nil {
+		if err == io.EOF && header != "" {
+			err = io.ErrUnexpectedEOF
+		}
+		return "", nil, err
+	}
+
+	fields := strings.Fields(header)
+	if len(fields) != 2 {
+		return "", nil, fmt.Errorf("malformed frame header: %q", header)
+	}
+	length, err := strconv.Atoi(fields[1])
+	if err != nil || length < 0 {
+		return "", nil, fmt.Errorf("malformed frame header: %q", header)
+	}
+
+	payload = make([]byte, length)
+	_, err = io.ReadFull(r, payload)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return fields[0], payload, err
+}
+
+// EncodeArguments encodes the arguments of an operation (e.g. ["apply",
+// "file:/etc/foo.conf"]) as the payload of a request frame.
+func EncodeArguments(args []string) []byte {
+	var buf bytes.Buffer
+	for _, arg := range args {
+		buf.WriteString(arg)
+		buf.WriteByte(0)
+	}
+	return buf.Bytes()
+}
+
+// DecodeArguments is the reverse operation of EncodeArguments.
+func DecodeArguments(payload []byte) []string {
+	str := strings.TrimSuffix(string(payload), "\000")
+	if str == "" {
+		return nil
+	}
+	return strings.Split(str, "\000")
+}
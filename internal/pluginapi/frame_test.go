@@ -0,0 +1,81 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package pluginapi
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestFrameRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	args := []string{"apply", "file:/etc/foo bar.conf"}
+	WriteFrame(&buf, FrameRequest, EncodeArguments(args))
+	WriteFrame(&buf, FrameStdout, []byte("multiple\nlines\n"))
+	WriteFrame(&buf, FrameStatus, []byte("0"))
+
+	r := bufio.NewReader(&buf)
+	expected := []struct {
+		frameType string
+		payload   string
+	}{
+		{FrameRequest, "apply\000file:/etc/foo bar.conf\000"},
+		{FrameStdout, "multiple\nlines\n"},
+		{FrameStatus, "0"},
+	}
+	for _, e := range expected {
+		frameType, payload, err := ReadFrame(r)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err.Error())
+		}
+		if frameType != e.frameType || string(payload) != e.payload {
+			t.Errorf("expected %s frame %q, got %s frame %q", e.frameType, e.payload, frameType, string(payload))
+		}
+		if frameType == FrameRequest {
+			decoded := DecodeArguments(payload)
+			if !reflect.DeepEqual(decoded, args) {
+				t.Errorf("expected arguments %#v, got %#v", args, decoded)
+			}
+		}
+	}
+
+	_, _, err := ReadFrame(r)
+	if err != io.EOF {
+		t.Errorf("expected io.EOF at end of stream, got %#v", err)
+	}
+}
+
+func TestFrameTruncated(t *testing.T) {
+	for _, input := range []string{"stdout 10\nshort", "stdout 3"} {
+		_, _, err := ReadFrame(bufio.NewReader(bytes.NewBufferString(input)))
+		if err != io.ErrUnexpectedEOF {
+			t.Errorf("expected io.ErrUnexpectedEOF for %q, got %#v", input, err)
+		}
+	}
+
+	_, _, err := ReadFrame(bufio.NewReader(bytes.NewBufferString("stdout\n")))
+	if err == nil {
+		t.Error("expected error for malformed frame header, got nil")
+	}
+}
@@ -0,0 +1,50 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package pluginapi
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// JournalEntryPath returns the path where the prior state of the given entity
+// is recorded in the journal directory, e.g.
+//
+//	JournalEntryPath("/var/lib/holo/journal/20260101-120000/files", "file:/etc/foo.conf")
+//	    == "/var/lib/holo/journal/20260101-120000/files/file:%2Fetc%2Ffoo.conf"
+//
+// Whether the entry is a file or a directory is up to the plugin.
+func JournalEntryPath(journalDir, entityID string) string {
+	return filepath.Join(journalDir, url.PathEscape(entityID))
+}
+
+// JournalEntryPathForPlugin is like JournalEntryPath, but uses the journal
+// directory that Holo passed to the plugin in $HOLO_JOURNAL_DIR. If there is
+// none (because the current operation is not journaled), an empty string is
+// returned.
+func JournalEntryPathForPlugin(entityID string) string {
+	journalDir := os.Getenv("HOLO_JOURNAL_DIR")
+	if journalDir == "" {
+		return ""
+	}
+	return JournalEntryPath(journalDir, entityID)
+}
@@ -0,0 +1,75 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package pluginapi
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// UseColors returns whether Holo has asked the plugin to use ANSI colors on
+// stderr (by setting HOLO_COLOR=always).
+func UseColors() bool {
+	return os.Getenv("HOLO_COLOR") == "always"
+}
+
+// Errorf formats and prints an error message with the "!!" prefix on stderr.
+func Errorf(text string, args ...interface{}) {
+	printMessage("!! ", text, args)
+}
+
+// Warnf formats and prints a warning message with the ">>" prefix on stderr.
+func Warnf(text string, args ...interface{}) {
+	printMessage(">> ", text, args)
+}
+
+func printMessage(prefix, text string, args []interface{}) {
+	if len(args) > 0 {
+		text = fmt.Sprintf(text, args...)
+	}
+	fmt.Fprintln(os.Stderr, formatMessage(prefix, strings.TrimSuffix(text, "\n"), UseColors()))
+}
+
+// formatMessage prefixes the message and, if requested, colors each of its
+// lines: Messages may contain additional lines with their own "!!" or ">>"
+// prefix (e.g. a list of parser errors below a summary).
+func formatMessage(prefix, text string, withColors bool) string {
+	lines := strings.Split(prefix+text, "\n")
+	if withColors {
+		colors := map[string]string{"!! ": "\x1b[1;31m", ">> ": "\x1b[1;33m"}
+		for idx, line := range lines {
+			color, ok := colors[line[:minInt(len(line), 3)]]
+			if !ok {
+				color = colors[prefix]
+			}
+			lines[idx] = color + line + "\x1b[0m"
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,45 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package pluginapi
+
+import "testing"
+
+func TestFormatMessage(t *testing.T) {
+	testcases := []struct {
+		prefix     string
+		text       string
+		withColors bool
+		expected   string
+	}{
+		{"!! ", "something failed", false, "!! something failed"},
+		{"!! ", "something failed", true, "\x1b[1;31m!! something failed\x1b[0m"},
+		{">> ", "be careful", true, "\x1b[1;33m>> be careful\x1b[0m"},
+		{"!! ", "File foo is invalid:\n>> bar", false, "!! File foo is invalid:\n>> bar"},
+		{"!! ", "File foo is invalid:\n>> bar", true, "\x1b[1;31m!! File foo is invalid:\x1b[0m\n\x1b[1;33m>> bar\x1b[0m"},
+		{">> ", "first line\nsecond line", true, "\x1b[1;33m>> first line\x1b[0m\n\x1b[1;33msecond line\x1b[0m"},
+	}
+	for _, tc := range testcases {
+		actual := formatMessage(tc.prefix, tc.text, tc.withColors)
+		if actual != tc.expected {
+			t.Errorf("expected %q, got %q", tc.expected, actual)
+		}
+	}
+}
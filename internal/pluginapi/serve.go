@@ -0,0 +1,151 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+// Package pluginapi contains the parts of holo-plugin-interface(7) that are
+// shared between Holo and the plugins that are implemented in Go.
+package pluginapi
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// Handler executes a single plugin operation. The args contain the operation
+// and its arguments, e.g. ["apply", "file:/etc/foo.conf"]. Output shall be
+// written to os.Stdout and os.Stderr as usual, and messages for Holo shall be
+// written into Messages().
+type Handler func(args []string) (exitCode int)
+
+var messages io.Writer
+
+// Messages returns the channel for structured messages to Holo, e.g. "not
+// changed\n" during the "apply" operation. In API version 3, this is file
+// descriptor 3. During Serve(), the messages are sent in message frames.
+func Messages() io.Writer {
+	if messages == nil {
+		messages = os.NewFile(3, "file descriptor 3")
+	}
+	return messages
+}
+
+// Serve implements the "serve" operation of plugin API version 4: it reads
+// request frames from stdin and executes them with the given handler until
+// stdin is closed. While a request is being executed, os.Stdout and os.Stderr
+// are redirected into the response frames.
+func Serve(handler Handler) (exitCode int) {
+	in := bufio.NewReader(os.Stdin)
+	out := &frameWriter{Writer: os.Stdout}
+	stderr := os.Stderr
+
+	for {
+		frameType, payload, err := ReadFrame(in)
+		if err == io.EOF {
+			//Holo has closed the session
+			return 0
+		}
+		if err == nil && frameType != FrameRequest {
+			err = fmt.Errorf("expected %s frame, got %s frame", FrameRequest, frameType)
+		}
+		if err == nil {
+			err = serveRequest(handler, DecodeArguments(payload), out)
+		}
+		if err != nil {
+			fmt.Fprintln(stderr, formatMessage("!! ", err.Error(), UseColors()))
+			return 1
+		}
+	}
+}
+
+func serveRequest(handler Handler, args []string, out *frameWriter) error {
+	stdoutReader, stdoutWriter, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	stderrReader, stderrWriter, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go out.CopyFrom(FrameStdout, stdoutReader, &wg)
+	go out.CopyFrom(FrameStderr, stderrReader, &wg)
+
+	//execute the operation with redirected output
+	var msg bytes.Buffer
+	realStdout, realStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr, messages = stdoutWriter, stderrWriter, &msg
+	exitCode := handler(args)
+	os.Stdout, os.Stderr, messages = realStdout, realStderr, nil
+
+	//wait for the output to be forwarded completely
+	stdoutWriter.Close()
+	stderrWriter.Close()
+	wg.Wait()
+	stdoutReader.Close()
+	stderrReader.Close()
+
+	if msg.Len() > 0 {
+		out.WriteFrame(FrameMessage, msg.Bytes())
+	}
+	out.WriteFrame(FrameStatus, []byte(strconv.Itoa(exitCode)))
+	return out.Err()
+}
+
+// frameWriter writes frames from multiple goroutines. The first write error
+// is remembered and reported by Err().
+type frameWriter struct {
+	Writer io.Writer
+	mutex  sync.Mutex
+	err    error
+}
+
+func (w *frameWriter) WriteFrame(frameType string, payload []byte) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.err == nil {
+		w.err = WriteFrame(w.Writer, frameType, payload)
+	}
+}
+
+func (w *frameWriter) CopyFrom(frameType string, r io.Reader, wg *sync.WaitGroup) {
+	defer wg.Done()
+	buf := make([]byte, 4096)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			w.WriteFrame(frameType, buf[:n])
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (w *frameWriter) Err() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.err
+}
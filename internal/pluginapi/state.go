@@ -0,0 +1,80 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+package pluginapi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+)
+
+// HashFile returns the hash of the file at the given path (or of the link
+// target, for symlinks) in the form used by ReportStateHashes(), or "absent"
+// if there is no such file. If the file cannot be read, an empty string is
+// returned.
+func HashFile(path string) string {
+	info, err := os.Lstat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "absent"
+		}
+		return ""
+	}
+
+	hash := sha256.New()
+	if info.Mode()&os.ModeType == os.ModeSymlink {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return ""
+		}
+		io.WriteString(hash, target)
+	} else {
+		file, err := os.Open(path)
+		if err != nil {
+			return ""
+		}
+		defer file.Close()
+		_, err = io.Copy(hash, file)
+		if err != nil {
+			return ""
+		}
+	}
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil))
+}
+
+// HashBytes returns the hash of the given textual representation of an
+// entity's state in the form used by ReportStateHashes().
+func HashBytes(buf []byte) string {
+	hash := sha256.Sum256(buf)
+	return "sha256:" + hex.EncodeToString(hash[:])
+}
+
+// ReportStateHashes tells Holo the hashes of the actual state of an entity
+// before and after it was changed by the "apply" or "force-apply" operation,
+// for recording them in Holo's history. Empty hashes are not reported.
+func ReportStateHashes(before, after string) error {
+	if before == "" || after == "" {
+		return nil
+	}
+	_, err := fmt.Fprintf(Messages(), "state before: %s\nstate after: %s\n", before, after)
+	return err
+}
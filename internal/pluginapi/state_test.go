@@ -0,0 +1,75 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+package pluginapi
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHashFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file")
+	if actual := HashFile(path); actual != "absent" {
+		t.Errorf("expected hash \"absent\" for missing file, got %q", actual)
+	}
+
+	err := os.WriteFile(path, []byte("hello\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "sha256:5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"
+	if actual := HashFile(path); actual != expected {
+		t.Errorf("expected hash %q, got %q", expected, actual)
+	}
+	if actual := HashBytes([]byte("hello\n")); actual != expected {
+		t.Errorf("expected hash %q for bytes, got %q", expected, actual)
+	}
+
+	//for symlinks, the link target is hashed
+	err = os.Symlink("hello\n", filepath.Join(dir, "link"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := HashFile(filepath.Join(dir, "link")); actual != expected {
+		t.Errorf("expected hash %q for symlink, got %q", expected, actual)
+	}
+}
+
+func TestReportStateHashes(t *testing.T) {
+	var buf bytes.Buffer
+	messages = &buf
+	defer func() { messages = nil }()
+
+	err := ReportStateHashes("absent", "sha256:1234")
+	if err == nil {
+		//incomplete hashes are not reported
+		err = ReportStateHashes("", "sha256:1234")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "state before: absent\nstate after: sha256:1234\n"
+	if buf.String() != expected {
+		t.Errorf("expected messages %q, got %q", expected, buf.String())
+	}
+}
@@ -0,0 +1,168 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+// Package procgroup runs child processes in their own process group, so that
+// they can be cancelled reliably.
+package procgroup
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// Process is a child process that was started by Start().
+type Process struct {
+	ctx     context.Context
+	cmd     *exec.Cmd
+	done    chan struct{}
+	copiers []*copier
+}
+
+// Start starts the given command in its own process group. When the context
+// is done before the command exits, the whole process group is killed. (When
+// only the child process was killed, its children would live on.)
+//
+// Since the process group is not the foreground process group of the
+// terminal, interrupts from the terminal (e.g. Ctrl-C) are not delivered to
+// the command. It is up to the caller to decide whether to let the command
+// finish or to cancel the context.
+func Start(ctx context.Context, cmd *exec.Cmd) (*Process, error) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+
+	//when cmd.Stdout or cmd.Stderr are not files, cmd.Wait() would block until
+	//every process that inherited the pipe has exited (including processes in
+	//other process groups, like the children of the command that also use
+	//this package), so we copy from the pipes ourselves to be able to stop
+	p := &Process{ctx: ctx, cmd: cmd, done: make(chan struct{})}
+	stdout, stderr := cmd.Stdout, cmd.Stderr
+	var err error
+	cmd.Stdout, err = p.pipe(stdout)
+	if err == nil {
+		if stderr == stdout {
+			//like exec.Cmd, use only one pipe (and thus one writer) for both
+			cmd.Stderr = cmd.Stdout
+		} else {
+			cmd.Stderr, err = p.pipe(stderr)
+		}
+	}
+	if err == nil {
+		err = cmd.Start()
+	}
+	for _, c := range p.copiers {
+		c.writer.Close() //or the copier will never see EOF
+		if err == nil {
+			go c.run()
+		} else {
+			c.reader.Close()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			//the context is also done when the caller cleans up after Wait()
+			//has returned, in which case the process group is already gone
+			select {
+			case <-p.done:
+			default:
+				p.Kill()
+			}
+		case <-p.done:
+		}
+	}()
+	return p, nil
+}
+
+// Run is like cmd.Run(), but uses Start() and Wait().
+func Run(ctx context.Context, cmd *exec.Cmd) error {
+	p, err := Start(ctx, cmd)
+	if err != nil {
+		return err
+	}
+	return p.Wait()
+}
+
+// Kill kills the process group of this process.
+func (p *Process) Kill() {
+	//the process group ID is the PID of its leader (see Setpgid above)
+	_ = syscall.Kill(-p.cmd.Process.Pid, syscall.SIGKILL) //failure means that the process group is already gone
+}
+
+// Wait is like cmd.Wait(). When the process group was killed because the
+// context was done, the context's error is returned instead of the error from
+// cmd.Wait().
+func (p *Process) Wait() error {
+	err := p.cmd.Wait()
+	close(p.done)
+	killed := p.ctx.Err() != nil
+	for _, c := range p.copiers {
+		if killed {
+			//do not wait for output from processes that escaped the process group
+			c.reader.Close()
+		}
+		<-c.finished
+		if err == nil && !killed {
+			err = c.err
+		}
+	}
+	if killed && err != nil {
+		return p.ctx.Err()
+	}
+	return err
+}
+
+func (p *Process) pipe(w io.Writer) (io.Writer, error) {
+	if w == nil {
+		return nil, nil
+	}
+	if _, isFile := w.(*os.File); isFile {
+		return w, nil
+	}
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	p.copiers = append(p.copiers, &copier{reader, writer, w, make(chan struct{}), nil})
+	return writer, nil
+}
+
+// copier copies the output of a process from a pipe into an io.Writer.
+type copier struct {
+	reader   *os.File
+	writer   *os.File
+	target   io.Writer
+	finished chan struct{}
+	err      error
+}
+
+func (c *copier) run() {
+	_, c.err = io.Copy(c.target, c.reader)
+	c.reader.Close()
+	close(c.finished)
+}
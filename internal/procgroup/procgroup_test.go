@@ -0,0 +1,70 @@
+/*******************************************************************************
+*
+* Copyright 2026 Stefan Majewsky <majewsky@gmx.net>
+*
+* This file is part of Holo.
+*
+* Holo is free software: you can redistribute it and/or modify it under the
+* terms of the GNU General Public License as published by the Free Software
+* Foundation, either version 3 of the License, or (at your option) any later
+* version.
+*
+* Holo is distributed in the hope that it will be useful, but WITHOUT ANY
+* WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR
+* A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License along with
+* Holo. If not, see <http://www.gnu.org/licenses/>.
+*
+*******************************************************************************/
+
+package procgroup
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	var out bytes.Buffer
+	cmd := exec.Command("/bin/sh", "-c", "echo foo; echo bar >&2")
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := Run(context.Background(), cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "foo\nbar\n" {
+		t.Errorf("expected output %q, got %q", "foo\nbar\n", out.String())
+	}
+
+	err = Run(context.Background(), exec.Command("/bin/sh", "-c", "exit 3"))
+	if err == nil || err.Error() != "exit status 3" {
+		t.Errorf("expected exit status 3, got %v", err)
+	}
+}
+
+func TestRunWithTimeout(t *testing.T) {
+	//the sleep is a child of the shell, so it holds on to the stdout pipe even
+	//if only the shell is killed
+	var out bytes.Buffer
+	cmd := exec.Command("/bin/sh", "-c", "echo started; sleep 10; echo finished")
+	cmd.Stdout = &out
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	startTime := time.Now()
+	err := Run(ctx, cmd)
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected DeadlineExceeded, got %v", err)
+	}
+	if duration := time.Since(startTime); duration > 5*time.Second {
+		t.Errorf("expected Run() to return after the timeout, but it took %s", duration)
+	}
+	if out.String() != "started\n" {
+		t.Errorf("expected output %q, got %q", "started\n", out.String())
+	}
+}
@@ -0,0 +1,6 @@
+#!/bin/sh
+
+# hold the lock file like another instance of Holo would (the lock is released
+# automatically when the testcase's shell exits)
+exec 9<>target/run/holo.pid
+flock --exclusive 9
@@ -0,0 +1,10 @@
+holo_wrapper_BINARY=$HOLO_BINARY
+holo_wrapper() {
+	case "$1" in
+		apply|diff|scan)
+			set -- "$@" "regex:("
+			;;
+	esac
+	$holo_wrapper_BINARY "$@"
+}
+HOLO_BINARY=holo_wrapper